@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/yagnikpt/kairos/internal/ai"
 	"github.com/yagnikpt/kairos/internal/app"
 	"github.com/yagnikpt/kairos/internal/commands"
 	"github.com/yagnikpt/kairos/internal/config"
 	"github.com/yagnikpt/kairos/internal/database"
+	"github.com/yagnikpt/kairos/internal/store"
 	"github.com/yagnikpt/kairos/internal/ui"
 )
 
@@ -26,20 +29,18 @@ func main() {
 	}
 	defer db.Close()
 
-	aiClient, err := ai.NewClient(cfg.GeminiAPIKey)
-	if err != nil {
-		ui.RenderError(fmt.Errorf("failed to init ai client: %w", err))
-		os.Exit(1)
-	}
-
 	app := &app.App{
-		DB:     db,
-		AI:     aiClient,
+		Store:  store.New(db),
 		Config: cfg,
 	}
 
+	// Ctrl-C cancels in-flight AI calls instead of killing the process, so
+	// commands get a chance to bail out before touching the database.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	rootCmd := commands.NewRootCmd(app)
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
 }
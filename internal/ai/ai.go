@@ -2,110 +2,93 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
+	"sort"
 
-	"google.golang.org/genai"
+	"github.com/yagnikpt/kairos/internal/config"
 )
 
-type Client struct {
-	client *genai.Client
-	model  string
+// Planner breaks goals down into milestones and subtasks and suggests break
+// content. Every AI provider implements it so commands never depend on a
+// specific backend. Calls honour ctx cancellation.
+type Planner interface {
+	GenerateHighLevelTasks(ctx context.Context, goal string, contextInfo string) (*Response, error)
+	GenerateSubTasks(ctx context.Context, parentTask string) (*Response, error)
+	Replan(ctx context.Context, req ReplanRequest) (*Response, error)
+	SplitTask(ctx context.Context, req SplitRequest) (*Response, error)
+	SuggestContent(ctx context.Context, interests []string) (string, error)
+	Info() Info
 }
 
-func NewClient(apiKey string) (*Client, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: apiKey,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create genai client: %w", err)
-	}
-
-	return &Client{
-		client: client,
-		model:  "gemini-2.0-flash", // Using a faster model if available, or fallback to user pref
-	}, nil
+// Response is a list of milestones or subtasks together with the raw model
+// output it was parsed from, kept so a saved plan can be audited later.
+type Response struct {
+	Items []PlanItem
+	Raw   string
 }
 
-func (c *Client) GenerateHighLevelTasks(goal string, contextInfo string) ([]string, error) {
-	prompt := fmt.Sprintf(`
-You are a productivity assistant.
-The user has a goal: "%s".
-%s
-Break this down into 3-5 high-level, actionable milestones or phases.
-Return ONLY a JSON array of strings, where each string is a task description.
-Example: ["Learn basic syntax", "Build a small project", "Read documentation"]
-`, goal, func() string {
-		if contextInfo != "" {
-			return fmt.Sprintf("Additional context: %s", contextInfo)
-		}
-		return ""
-	}())
-
-	return c.generateList(prompt)
+// Info identifies what produced a plan.
+type Info struct {
+	Provider      string
+	Model         string
+	PromptVersion int
 }
 
-func (c *Client) GenerateSubTasks(parentTask string) ([]string, error) {
-	prompt := fmt.Sprintf(`
-You are a productivity assistant.
-The user has a high-level task: "%s".
-Break this down into 3-5 small, actionable sub-tasks that can be done in 15-30 minutes.
-Return ONLY a JSON array of strings.
-`, parentTask)
+// ReplanRequest describes a goal part-way through: what has been finished so
+// far and what the user wants changed. Replan returns new milestones for the
+// remaining work only.
+type ReplanRequest struct {
+	Goal      string
+	Context   string
+	Completed []CompletedTask
+	Note      string // Optional note from the user on what changed
+}
 
-	return c.generateList(prompt)
+// SplitRequest describes a task that turned out too big, with what it's part
+// of. SplitTask returns the smaller tasks to do instead.
+type SplitRequest struct {
+	Goal          string
+	Context       string
+	Parents       []string // Milestone first, then any tasks in between
+	Task          string
+	EstimatedMins int // 0 if the task had no estimate
 }
 
-func (c *Client) SuggestContent(interests []string) (string, error) {
-	prompt := fmt.Sprintf(`
-The user needs a break. Their interests are: %s.
-Suggest a topic or a type of article/paper they should read to relax but stay inspired.
-Keep it short and encouraging.
-`, strings.Join(interests, ", "))
+// CompletedTask is a task that is already DONE or SKIPPED.
+type CompletedTask struct {
+	Title  string
+	Status string
+}
 
-	resp, err := c.client.Models.GenerateContent(context.Background(), c.model, genai.Text(prompt), nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate content: %w", err)
-	}
+// Factory builds a Planner from the user's config.
+type Factory func(cfg *config.Config) (Planner, error)
 
-	if resp == nil || len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no content generated")
-	}
+var providers = map[string]Factory{}
 
-	var textBuilder strings.Builder
-	for _, part := range resp.Candidates[0].Content.Parts {
-		textBuilder.WriteString(part.Text)
+// Register makes a provider available under name. It is meant to be called
+// from the init function of the file implementing the provider.
+func Register(name string, factory Factory) {
+	if _, exists := providers[name]; exists {
+		panic(fmt.Sprintf("ai: provider %q registered twice", name))
 	}
-	return textBuilder.String(), nil
+	providers[name] = factory
 }
 
-func (c *Client) generateList(prompt string) ([]string, error) {
-	resp, err := c.client.Models.GenerateContent(context.Background(), c.model, genai.Text(prompt), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
+// Providers returns the names of all registered providers, sorted.
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	if resp == nil || len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("no content generated")
-	}
-
-	var textBuilder strings.Builder
-	for _, part := range resp.Candidates[0].Content.Parts {
-		textBuilder.WriteString(part.Text)
-	}
-	text := textBuilder.String()
-
-	text = strings.TrimPrefix(text, "```json")
-	text = strings.TrimPrefix(text, "```")
-	text = strings.TrimSuffix(text, "```")
-	text = strings.TrimSpace(text)
-
-	var tasks []string
-	if err := json.Unmarshal([]byte(text), &tasks); err != nil {
-		return nil, fmt.Errorf("failed to parse json response: %w, text: %s", err, text)
+// New builds the provider registered under name.
+func New(name string, cfg *config.Config) (Planner, error) {
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ai provider %q (available: %v)", name, Providers())
 	}
-
-	return tasks, nil
+	return factory(cfg)
 }
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"sync"
+)
+
+// SubTaskResult is the outcome of generating subtasks for one milestone.
+type SubTaskResult struct {
+	Index    int
+	Subtasks []PlanItem
+	Raw      string // The planner's reply the subtasks were parsed from
+	Err      error
+}
+
+// GenerateSubTasksConcurrently calls GenerateSubTasks for every parent with
+// at most limit calls in flight. Results are indexed like parents, so the
+// order doesn't depend on which call finishes first. onStart and onDone, if
+// set, are called from worker goroutines and must be safe for concurrent use.
+func GenerateSubTasksConcurrently(ctx context.Context, p Planner, parents []string, limit int, onStart func(i int), onDone func(SubTaskResult)) []SubTaskResult {
+	if limit < 1 {
+		limit = 1
+	}
+
+	results := make([]SubTaskResult, len(parents))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, parent := range parents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// A free slot and a cancelled context can both be ready; select
+			// picks either, so check again once a slot is taken.
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+			if err := ctx.Err(); err != nil {
+				results[i] = SubTaskResult{Index: i, Err: err}
+				if onDone != nil {
+					onDone(results[i])
+				}
+				return
+			}
+
+			if onStart != nil {
+				onStart(i)
+			}
+			resp, err := p.GenerateSubTasks(ctx, parent)
+			results[i] = SubTaskResult{Index: i, Err: err}
+			if err == nil {
+				results[i].Subtasks, results[i].Raw = resp.Items, resp.Raw
+			}
+			if onDone != nil {
+				onDone(results[i])
+			}
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
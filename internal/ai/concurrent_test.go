@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingPlanner records how many GenerateSubTasks calls run at once. Calls
+// finish in reverse order of their parents' position, and a parent called
+// "fail" gets an error.
+type countingPlanner struct {
+	Planner
+	inFlight, peak atomic.Int32
+}
+
+func (p *countingPlanner) GenerateSubTasks(ctx context.Context, parent string) (*Response, error) {
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	var i int
+	fmt.Sscanf(parent, "M%d", &i)
+	time.Sleep(time.Duration(10-i) * time.Millisecond)
+	if parent == "fail" {
+		return nil, errors.New("rate limited")
+	}
+	return &Response{Items: []PlanItem{{Title: parent + " subtask"}}, Raw: parent}, nil
+}
+
+func TestGenerateSubTasksConcurrently(t *testing.T) {
+	parents := []string{"M0", "M1", "M2", "fail", "M4", "M5", "M6", "M7"}
+	for _, limit := range []int{0, 1, 3, 20} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			p := &countingPlanner{}
+			var started, done atomic.Int32
+			results := GenerateSubTasksConcurrently(context.Background(), p, parents, limit,
+				func(int) { started.Add(1) },
+				func(SubTaskResult) { done.Add(1) })
+
+			if len(results) != len(parents) {
+				t.Fatalf("%d results for %d parents", len(results), len(parents))
+			}
+			for i, r := range results {
+				if r.Index != i {
+					t.Errorf("results[%d].Index = %d", i, r.Index)
+				}
+				if parents[i] == "fail" {
+					if r.Err == nil {
+						t.Errorf("results[%d] has no error", i)
+					}
+					continue
+				}
+				if r.Err != nil || len(r.Subtasks) != 1 || r.Subtasks[0].Title != parents[i]+" subtask" || r.Raw != parents[i] {
+					t.Errorf("results[%d] = %+v, want the subtasks of %s", i, r, parents[i])
+				}
+			}
+
+			want := int32(min(max(limit, 1), len(parents)))
+			if peak := p.peak.Load(); peak > want {
+				t.Errorf("%d calls in flight, want at most %d", peak, want)
+			}
+			if started.Load() != int32(len(parents)) || done.Load() != int32(len(parents)) {
+				t.Errorf("onStart ran %d times and onDone %d, want %d each", started.Load(), done.Load(), len(parents))
+			}
+		})
+	}
+}
+
+func TestGenerateSubTasksConcurrentlyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &countingPlanner{}
+	results := GenerateSubTasksConcurrently(ctx, p, []string{"M0", "M1", "M2"}, 1, nil, nil)
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+	if p.peak.Load() != 0 {
+		t.Error("the planner was called after cancelling")
+	}
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yagnikpt/kairos/internal/config"
+	"google.golang.org/genai"
+)
+
+const defaultGeminiModel = "gemini-2.0-flash"
+
+func init() {
+	Register("gemini", func(cfg *config.Config) (Planner, error) {
+		if cfg.GeminiAPIKey == "" {
+			if err := cfg.PromptGeminiKey(); err != nil {
+				return nil, err
+			}
+		}
+		model := cfg.Model
+		if model == "" {
+			model = defaultGeminiModel
+		}
+		return NewGeminiClient(cfg.GeminiAPIKey, model, NewRetryPolicy(cfg.AITimeout, cfg.AIMaxRetries))
+	})
+}
+
+// GeminiClient is the Planner backed by Google's Gemini API.
+type GeminiClient struct {
+	client *genai.Client
+	model  string
+	retry  RetryPolicy
+}
+
+func NewGeminiClient(apiKey string, model string, retry RetryPolicy) (*GeminiClient, error) {
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey: apiKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create genai client: %w", err)
+	}
+
+	return &GeminiClient{
+		client: client,
+		model:  model,
+		retry:  retry,
+	}, nil
+}
+
+func (c *GeminiClient) GenerateHighLevelTasks(ctx context.Context, goal string, contextInfo string) (*Response, error) {
+	return c.generateList(ctx, highLevelTasksPrompt(goal, contextInfo))
+}
+
+func (c *GeminiClient) GenerateSubTasks(ctx context.Context, parentTask string) (*Response, error) {
+	return c.generateList(ctx, subTasksPrompt(parentTask))
+}
+
+func (c *GeminiClient) Replan(ctx context.Context, req ReplanRequest) (*Response, error) {
+	return c.generateList(ctx, replanPrompt(req))
+}
+
+func (c *GeminiClient) SplitTask(ctx context.Context, req SplitRequest) (*Response, error) {
+	return c.generateList(ctx, splitTaskPrompt(req))
+}
+
+func (c *GeminiClient) Info() Info {
+	return Info{Provider: "gemini", Model: c.model, PromptVersion: PromptVersion}
+}
+
+func (c *GeminiClient) SuggestContent(ctx context.Context, interests []string) (string, error) {
+	return c.generate(ctx, suggestContentPrompt(interests), nil)
+}
+
+func (c *GeminiClient) generateList(ctx context.Context, prompt string) (*Response, error) {
+	text, err := c.generate(ctx, prompt, &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   planItemsGenaiSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	items, err := parsePlanItems(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Items: items, Raw: text}, nil
+}
+
+func (c *GeminiClient) generate(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (string, error) {
+	resp, err := withRetry(ctx, c.retry, func(ctx context.Context) (*genai.GenerateContentResponse, error) {
+		return c.client.Models.GenerateContent(ctx, c.model, genai.Text(prompt), config)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no content generated")
+	}
+
+	var textBuilder strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		textBuilder.WriteString(part.Text)
+	}
+	return textBuilder.String(), nil
+}
@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yagnikpt/kairos/internal/config"
+)
+
+func init() {
+	Register("openai", func(cfg *config.Config) (Planner, error) {
+		return NewOpenAIClient(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.Model, NewRetryPolicy(cfg.AITimeout, cfg.AIMaxRetries))
+	})
+}
+
+// OpenAIClient is the Planner backed by any server speaking the OpenAI
+// /v1/chat/completions protocol, such as Ollama, llama.cpp server or vLLM.
+type OpenAIClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	retry      RetryPolicy
+}
+
+func NewOpenAIClient(baseURL string, apiKey string, model string, retry RetryPolicy) (*OpenAIClient, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai provider needs openai_base_url, e.g. http://localhost:11434/v1")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("openai provider needs a model, e.g. llama3.1")
+	}
+
+	return &OpenAIClient{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		retry:      retry,
+	}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Stream         bool            `json:"stream"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// responseFormat asks servers that support structured output to constrain
+// the reply to a JSON schema. Servers that don't are still covered by
+// parsePlanItems validating the reply.
+type responseFormat struct {
+	Type       string     `json:"type"`
+	JSONSchema jsonSchema `json:"json_schema"`
+}
+
+type jsonSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (c *OpenAIClient) GenerateHighLevelTasks(ctx context.Context, goal string, contextInfo string) (*Response, error) {
+	return c.generateList(ctx, highLevelTasksPrompt(goal, contextInfo))
+}
+
+func (c *OpenAIClient) GenerateSubTasks(ctx context.Context, parentTask string) (*Response, error) {
+	return c.generateList(ctx, subTasksPrompt(parentTask))
+}
+
+func (c *OpenAIClient) Replan(ctx context.Context, req ReplanRequest) (*Response, error) {
+	return c.generateList(ctx, replanPrompt(req))
+}
+
+func (c *OpenAIClient) SplitTask(ctx context.Context, req SplitRequest) (*Response, error) {
+	return c.generateList(ctx, splitTaskPrompt(req))
+}
+
+func (c *OpenAIClient) Info() Info {
+	return Info{Provider: "openai", Model: c.model, PromptVersion: PromptVersion}
+}
+
+func (c *OpenAIClient) SuggestContent(ctx context.Context, interests []string) (string, error) {
+	return c.complete(ctx, suggestContentPrompt(interests), nil)
+}
+
+func (c *OpenAIClient) generateList(ctx context.Context, prompt string) (*Response, error) {
+	text, err := c.complete(ctx, prompt, &responseFormat{
+		Type:       "json_schema",
+		JSONSchema: jsonSchema{Name: "plan_items", Schema: planItemsJSONSchema},
+	})
+	if err != nil {
+		return nil, err
+	}
+	items, err := parsePlanItems(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Items: items, Raw: text}, nil
+}
+
+func (c *OpenAIClient) complete(ctx context.Context, prompt string, format *responseFormat) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:          c.model,
+		Messages:       []chatMessage{{Role: "user", Content: prompt}},
+		ResponseFormat: format,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := withRetry(ctx, c.retry, func(ctx context.Context) (*chatResponse, error) {
+		return c.post(ctx, body)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	if len(out.Choices) == 0 || out.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no content generated")
+	}
+
+	return out.Choices[0].Message.Content, nil
+}
+
+func (c *OpenAIClient) post(ctx context.Context, body []byte) (*chatResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &statusError{code: resp.StatusCode, msg: strings.TrimSpace(string(msg))}
+	}
+
+	var out chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &out, nil
+}
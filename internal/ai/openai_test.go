@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// chatReply is a /chat/completions response with the given content.
+func chatReply(content string) string {
+	out, _ := json.Marshal(map[string]any{
+		"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}}},
+	})
+	return string(out)
+}
+
+func TestOpenAIClient(t *testing.T) {
+	var got struct {
+		path, auth, contentType string
+		body                    chatRequest
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.Path
+		got.auth = r.Header.Get("Authorization")
+		got.contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got.body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.Write([]byte(chatReply("```json\n" + `[{"title": "Read", "estimated_duration_mins": 20, "rationale": "Basics first"}]` + "\n```")))
+	}))
+	defer srv.Close()
+
+	c, err := NewOpenAIClient(srv.URL+"/v1/", "secret", "llama3.1", testPolicy(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.GenerateSubTasks(context.Background(), "Learn Go")
+	if err != nil {
+		t.Fatalf("GenerateSubTasks: %v", err)
+	}
+
+	if want := []PlanItem{{Title: "Read", EstimatedDurationMins: 20, Rationale: "Basics first"}}; !reflect.DeepEqual(resp.Items, want) {
+		t.Errorf("items = %+v, want %+v", resp.Items, want)
+	}
+	if !strings.HasPrefix(resp.Raw, "```json") {
+		t.Errorf("raw = %q, want the reply as sent", resp.Raw)
+	}
+	if got.path != "/v1/chat/completions" {
+		t.Errorf("path = %q", got.path)
+	}
+	if got.auth != "Bearer secret" || got.contentType != "application/json" {
+		t.Errorf("headers = %q, %q", got.auth, got.contentType)
+	}
+	body := got.body
+	if body.Model != "llama3.1" || body.Stream {
+		t.Errorf("model = %q, stream = %v", body.Model, body.Stream)
+	}
+	if len(body.Messages) != 1 || body.Messages[0].Role != "user" || !strings.Contains(body.Messages[0].Content, "Learn Go") {
+		t.Errorf("messages = %+v", body.Messages)
+	}
+	if f := body.ResponseFormat; f == nil || f.Type != "json_schema" || f.JSONSchema.Name != "plan_items" || f.JSONSchema.Schema == nil {
+		t.Errorf("response_format = %+v", f)
+	}
+}
+
+func TestOpenAIClientWithoutKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth, ok := r.Header["Authorization"]; ok {
+			t.Errorf("Authorization = %q, want none without an API key", auth)
+		}
+		// Plain completions don't ask for structured output
+		var body chatRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.ResponseFormat != nil {
+			t.Errorf("response_format = %+v, want none", body.ResponseFormat)
+		}
+		w.Write([]byte(chatReply("Go for a walk.")))
+	}))
+	defer srv.Close()
+
+	c, err := NewOpenAIClient(srv.URL, "", "llama3.1", testPolicy(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.SuggestContent(context.Background(), nil); err != nil || got != "Go for a walk." {
+		t.Errorf("SuggestContent = %q, %v", got, err)
+	}
+}
+
+func TestOpenAIClientErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantCalls int32
+		wantErr   string
+	}{
+		{name: "client error", status: http.StatusUnauthorized, body: "bad key", wantCalls: 1, wantErr: "401 Unauthorized: bad key"},
+		{name: "server error is retried", status: http.StatusBadGateway, body: "upstream down", wantCalls: 3, wantErr: "giving up after 3 attempts"},
+		{name: "rate limit is retried", status: http.StatusTooManyRequests, wantCalls: 3, wantErr: "429"},
+		{name: "undecodable body", status: http.StatusOK, body: "<html>", wantCalls: 1, wantErr: "failed to decode response"},
+		{name: "no choices", status: http.StatusOK, body: `{"choices": []}`, wantCalls: 1, wantErr: "no content generated"},
+		{name: "not a plan", status: http.StatusOK, body: chatReply("I can't do that."), wantCalls: 1, wantErr: "no json array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c, err := NewOpenAIClient(srv.URL, "secret", "llama3.1", testPolicy(2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.GenerateHighLevelTasks(context.Background(), "Learn Go", "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("requests = %d, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+
+	if _, err := NewOpenAIClient("", "", "llama3.1", testPolicy(0)); err == nil {
+		t.Error("NewOpenAIClient without a base URL didn't fail")
+	}
+}
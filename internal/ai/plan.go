@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// PlanItem is a milestone or subtask proposed by a Planner.
+type PlanItem struct {
+	Title                 string `json:"title"`
+	EstimatedDurationMins int    `json:"estimated_duration_mins"`
+	Rationale             string `json:"rationale"`
+}
+
+// Milestone is a planned milestone together with its subtasks and the raw
+// planner reply they came from. NeedsPlanning is set when the subtasks could
+// not be generated yet, and PlanningError says why.
+type Milestone struct {
+	PlanItem
+	Subtasks      []PlanItem
+	RawResponse   string
+	NeedsPlanning bool
+	PlanningError string
+}
+
+// planItemFields are the fields of every item in a planner reply, in the
+// order the model should write them. Both schemas below are built from it so
+// they can't drift apart.
+var planItemFields = []struct{ name, typ, description string }{
+	{"title", "string", "Short, actionable task description"},
+	{"estimated_duration_mins", "integer", "Estimated time to finish, in minutes"},
+	{"rationale", "string", "One sentence on why this step matters"},
+}
+
+// planItemsJSONSchema describes the response every LLM backend must return:
+// an array of PlanItem objects.
+var planItemsJSONSchema = func() map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for _, f := range planItemFields {
+		properties[f.name] = map[string]any{"type": f.typ, "description": f.description}
+		required = append(required, f.name)
+	}
+	return map[string]any{
+		"type":  "array",
+		"items": map[string]any{"type": "object", "properties": properties, "required": required},
+	}
+}()
+
+// planItemsGenaiSchema is planItemsJSONSchema in genai's own schema type.
+var planItemsGenaiSchema = func() *genai.Schema {
+	item := &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{}}
+	for _, f := range planItemFields {
+		item.Properties[f.name] = &genai.Schema{Type: genai.Type(strings.ToUpper(f.typ)), Description: f.description}
+		item.Required = append(item.Required, f.name)
+		item.PropertyOrdering = append(item.PropertyOrdering, f.name)
+	}
+	return &genai.Schema{Type: genai.TypeArray, Items: item}
+}()
+
+// planObject is one item of a reply as decoded. Pointers tell a missing
+// required field apart from a zero value.
+type planObject struct {
+	Title                 *string `json:"title"`
+	EstimatedDurationMins *int    `json:"estimated_duration_mins"`
+	Rationale             string  `json:"rationale"`
+}
+
+// parsePlanItems extracts the JSON array of plan items from a model response
+// and checks every item has a title and a positive estimate. Backends
+// without native structured output may surround the array with prose or
+// code fences, which can contain brackets of their own, so the array is the
+// first one starting at a '[' that decodes as a non-empty list of objects.
+func parsePlanItems(text string) ([]PlanItem, error) {
+	var objects []planObject
+	var schemaErr error
+	found := false
+	for i := 0; i < len(text) && !found; i++ {
+		if text[i] != '[' {
+			continue
+		}
+		var candidate []planObject
+		err := json.NewDecoder(strings.NewReader(text[i:])).Decode(&candidate)
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case err == nil:
+			objects = candidate
+			found = len(candidate) > 0
+		case errors.As(err, &typeErr) && schemaErr == nil:
+			// Valid JSON of the wrong shape, e.g. an array of strings
+			schemaErr = err
+		}
+	}
+
+	switch {
+	case found:
+	case objects != nil:
+		return nil, fmt.Errorf("response contained no tasks")
+	case schemaErr != nil:
+		return nil, fmt.Errorf("response does not match schema: %w, text: %s", schemaErr, text)
+	default:
+		return nil, fmt.Errorf("no json array in response: %s", text)
+	}
+
+	items := make([]PlanItem, 0, len(objects))
+	for i, obj := range objects {
+		if obj.Title == nil || strings.TrimSpace(*obj.Title) == "" {
+			return nil, fmt.Errorf("task %d is missing a title", i+1)
+		}
+		if obj.EstimatedDurationMins == nil || *obj.EstimatedDurationMins <= 0 {
+			return nil, fmt.Errorf("task %d is missing a positive estimated_duration_mins", i+1)
+		}
+		items = append(items, PlanItem{
+			Title:                 strings.TrimSpace(*obj.Title),
+			EstimatedDurationMins: *obj.EstimatedDurationMins,
+			Rationale:             strings.TrimSpace(obj.Rationale),
+		})
+	}
+	return items, nil
+}
+
+// Titles returns the titles of items, in order.
+func Titles(items []PlanItem) []string {
+	titles := make([]string, len(items))
+	for i, item := range items {
+		titles[i] = item.Title
+	}
+	return titles
+}
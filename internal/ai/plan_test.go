@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestParsePlanItems(t *testing.T) {
+	read := []PlanItem{{Title: "Read the docs", EstimatedDurationMins: 30, Rationale: "Know the basics"}}
+	const readJSON = `[{"title": " Read the docs ", "estimated_duration_mins": 30, "rationale": "Know the basics"}]`
+
+	tests := []struct {
+		name    string
+		text    string
+		want    []PlanItem
+		wantErr string
+	}{
+		{name: "bare array", text: readJSON, want: read},
+		{name: "surrounding prose", text: "Here is your plan:\n" + readJSON + "\nGood luck!", want: read},
+		{name: "code fence", text: "```json\n" + readJSON + "\n```", want: read},
+		{name: "brackets before", text: "Here is the plan [v2]:\n" + readJSON, want: read},
+		{name: "brackets after", text: readJSON + "\n[1] see docs", want: read},
+		{name: "brackets around", text: "Notes [a] and [] first.\n" + readJSON + "\n[1] see docs", want: read},
+		{name: "brackets in a title", text: `[{"title": "Read [ch. 1]", "estimated_duration_mins": 5}]`, want: []PlanItem{{Title: "Read [ch. 1]", EstimatedDurationMins: 5}}},
+		{
+			name: "rationale is optional",
+			text: `[{"title": "Read", "estimated_duration_mins": 5}]`,
+			want: []PlanItem{{Title: "Read", EstimatedDurationMins: 5}},
+		},
+		{name: "missing title", text: `[{"estimated_duration_mins": 30}]`, wantErr: "task 1 is missing a title"},
+		{name: "empty title", text: `[{"title": "  ", "estimated_duration_mins": 30}]`, wantErr: "task 1 is missing a title"},
+		{
+			name:    "negative estimate",
+			text:    `[{"title": "Read", "estimated_duration_mins": 10}, {"title": "Write", "estimated_duration_mins": -5}]`,
+			wantErr: "task 2 is missing a positive estimated_duration_mins",
+		},
+		{name: "missing estimate", text: `[{"title": "Read"}]`, wantErr: "task 1 is missing a positive estimated_duration_mins"},
+		{name: "empty array", text: `[]`, wantErr: "response contained no tasks"},
+		{name: "object instead of array", text: `{"title": "Read", "estimated_duration_mins": 30}`, wantErr: "no json array"},
+		{name: "array of strings", text: `["Read", "Write"]`, wantErr: "does not match schema"},
+		{name: "no json", text: "Sorry, I can't help with that.", wantErr: "no json array"},
+		{name: "only brackets in prose", text: "See [1] and [the docs].", wantErr: "does not match schema"},
+		{name: "unclosed array", text: `[{"title": "Read"`, wantErr: "no json array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePlanItems(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePlanItems: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The Gemini schema must say the same as the JSON schema other backends get.
+func TestPlanItemSchemasMatch(t *testing.T) {
+	items := planItemsJSONSchema["items"].(map[string]any)
+	props := items["properties"].(map[string]any)
+	g := planItemsGenaiSchema.Items
+
+	if planItemsGenaiSchema.Type != genai.TypeArray || g.Type != genai.TypeObject {
+		t.Errorf("genai schema types = %s of %s, want an array of objects", planItemsGenaiSchema.Type, g.Type)
+	}
+	if !reflect.DeepEqual(g.Required, items["required"]) {
+		t.Errorf("required = %v, want %v", g.Required, items["required"])
+	}
+	if len(g.Properties) != len(props) {
+		t.Fatalf("genai schema has %d properties, want %d", len(g.Properties), len(props))
+	}
+	for name, p := range props {
+		prop := p.(map[string]any)
+		gp, ok := g.Properties[name]
+		if !ok {
+			t.Errorf("genai schema is missing %q", name)
+			continue
+		}
+		if !strings.EqualFold(string(gp.Type), prop["type"].(string)) || gp.Description != prop["description"] {
+			t.Errorf("%s = %s %q, want %s %q", name, gp.Type, gp.Description, prop["type"], prop["description"])
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prompts are shared by every LLM backend so that switching providers only
+// changes who answers, not what is asked.
+
+// PromptVersion is stored with every plan. Bump it whenever a prompt or the
+// response schema changes.
+const PromptVersion = 2
+
+func highLevelTasksPrompt(goal string, contextInfo string) string {
+	return fmt.Sprintf(`
+You are a productivity assistant.
+The user has a goal: "%s".
+%s
+Break this down into 3-5 high-level, actionable milestones or phases.
+Return ONLY a JSON array of objects with these fields:
+- "title": the milestone description
+- "estimated_duration_mins": how long the milestone will take, in minutes
+- "rationale": one sentence on why this milestone matters
+Example: [{"title": "Learn basic syntax", "estimated_duration_mins": 240, "rationale": "Everything else builds on it."}]
+`, goal, func() string {
+		if contextInfo != "" {
+			return fmt.Sprintf("Additional context: %s", contextInfo)
+		}
+		return ""
+	}())
+}
+
+func replanPrompt(req ReplanRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+You are a productivity assistant.
+The user is part-way through a goal: "%s".
+`, req.Goal)
+	if req.Context != "" {
+		fmt.Fprintf(&b, "Additional context: %s\n", req.Context)
+	}
+	if len(req.Completed) > 0 {
+		b.WriteString("Work already finished or skipped:\n")
+		for _, c := range req.Completed {
+			fmt.Fprintf(&b, "- [%s] %s\n", c.Status, c.Title)
+		}
+	} else {
+		b.WriteString("Nothing has been finished yet.\n")
+	}
+	if req.Note != "" {
+		fmt.Fprintf(&b, "The user says this has changed: %s\n", req.Note)
+	}
+	b.WriteString(`Plan ONLY the remaining work as 2-5 high-level, actionable milestones. Do not repeat finished work.
+Return ONLY a JSON array of objects with these fields:
+- "title": the milestone description
+- "estimated_duration_mins": how long the milestone will take, in minutes
+- "rationale": one sentence on why this milestone matters given the progress so far
+`)
+	return b.String()
+}
+
+func subTasksPrompt(parentTask string) string {
+	return fmt.Sprintf(`
+You are a productivity assistant.
+The user has a high-level task: "%s".
+Break this down into 3-5 small, actionable sub-tasks that can be done in 15-30 minutes.
+Return ONLY a JSON array of objects with these fields:
+- "title": the sub-task description
+- "estimated_duration_mins": how long the sub-task will take, in minutes
+- "rationale": one sentence on why this sub-task matters
+`, parentTask)
+}
+
+func splitTaskPrompt(req SplitRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+You are a productivity assistant.
+The user is working on a goal: "%s".
+`, req.Goal)
+	if req.Context != "" {
+		fmt.Fprintf(&b, "Additional context: %s\n", req.Context)
+	}
+	if len(req.Parents) > 0 {
+		fmt.Fprintf(&b, "They are on this part of the plan: %s.\n", strings.Join(req.Parents, " > "))
+	}
+	fmt.Fprintf(&b, "One of its tasks is \"%s\"", req.Task)
+	if req.EstimatedMins > 0 {
+		fmt.Fprintf(&b, ", estimated at %d minutes,", req.EstimatedMins)
+	}
+	b.WriteString(` and it turned out bigger than planned.
+Break it down into 2-5 smaller, actionable sub-tasks that can be done in 15-30 minutes. Stay within the task; don't plan the rest of the goal.
+Return ONLY a JSON array of objects with these fields:
+- "title": the sub-task description
+- "estimated_duration_mins": how long the sub-task will take, in minutes
+- "rationale": one sentence on why this sub-task matters
+`)
+	return b.String()
+}
+
+func suggestContentPrompt(interests []string) string {
+	return fmt.Sprintf(`
+The user needs a break. Their interests are: %s.
+Suggest a topic or a type of article/paper they should read to relax but stay inspired.
+Keep it short and encouraging.
+`, strings.Join(interests, ", "))
+}
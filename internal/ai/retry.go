@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// RetryPolicy bounds every call to a remote provider: each attempt gets its
+// own timeout, and rate limits or server errors are retried with
+// exponential backoff. A zero timeout means attempts never time out.
+type RetryPolicy struct {
+	timeout     time.Duration
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+func NewRetryPolicy(timeout time.Duration, maxRetries int) RetryPolicy {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return RetryPolicy{
+		timeout:     timeout,
+		maxAttempts: maxRetries + 1,
+		baseDelay:   time.Second,
+	}
+}
+
+// statusError is returned by HTTP backends for non-2xx responses.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.code, http.StatusText(e.code), e.msg)
+}
+
+// retryable reports whether a failed attempt is worth repeating: rate limits,
+// server errors and attempts that ran into their own timeout.
+func retryable(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500
+	}
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code == http.StatusTooManyRequests || statusErr.code >= 500
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func withRetry[T any](ctx context.Context, p RetryPolicy, call func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	var err error
+	for attempt := 0; attempt < p.maxAttempts; attempt++ {
+		if attempt > 0 {
+			// 1s, 2s, 4s, ... plus up to 50% jitter
+			delay := p.baseDelay << (attempt - 1)
+			delay += rand.N(delay/2 + 1)
+			select {
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if p.timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		}
+		var res T
+		res, err = call(attemptCtx)
+		cancel()
+
+		if err == nil {
+			return res, nil
+		}
+		// The caller gave up (e.g. Ctrl-C); don't mistake it for a timeout.
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		if !retryable(err) {
+			return zero, err
+		}
+	}
+	return zero, fmt.Errorf("giving up after %d attempts: %w", p.maxAttempts, err)
+}
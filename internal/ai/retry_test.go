@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&statusError{code: http.StatusTooManyRequests}, true},
+		{&statusError{code: http.StatusInternalServerError}, true},
+		{&statusError{code: http.StatusServiceUnavailable}, true},
+		{fmt.Errorf("planning: %w", &statusError{code: http.StatusBadGateway}), true},
+		{&statusError{code: http.StatusBadRequest}, false},
+		{&statusError{code: http.StatusUnauthorized}, false},
+		{&statusError{code: http.StatusNotFound}, false},
+		{genai.APIError{Code: http.StatusTooManyRequests}, true},
+		{genai.APIError{Code: http.StatusInternalServerError}, true},
+		{genai.APIError{Code: http.StatusForbidden}, false},
+		{context.DeadlineExceeded, true},
+		{errors.New("no json array in response"), false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// testPolicy retries quickly so tests don't wait on real backoff.
+func testPolicy(maxRetries int) RetryPolicy {
+	p := NewRetryPolicy(0, maxRetries)
+	p.baseDelay = time.Millisecond
+	return p
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error // returned by successive attempts; nil once they run out
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "rate limit then success", errs: []error{&statusError{code: 429}}, wantCalls: 2},
+		{name: "server errors then success", errs: []error{&statusError{code: 500}, &statusError{code: 503}}, wantCalls: 3},
+		{name: "client error", errs: []error{&statusError{code: 400}}, wantCalls: 1, wantErr: true},
+		{
+			name:      "stops at max retries",
+			errs:      []error{&statusError{code: 500}, &statusError{code: 500}, &statusError{code: 500}, &statusError{code: 500}, &statusError{code: 500}},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got, err := withRetry(context.Background(), testPolicy(2), func(ctx context.Context) (string, error) {
+				calls++
+				if calls <= len(tt.errs) {
+					return "", tt.errs[calls-1]
+				}
+				return "ok", nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("withRetry = %q, want an error", got)
+				}
+				var statusErr *statusError
+				if !errors.As(err, &statusErr) {
+					t.Errorf("error %v doesn't wrap the last attempt's", err)
+				}
+				return
+			}
+			if err != nil || got != "ok" {
+				t.Errorf("withRetry = %q, %v; want ok", got, err)
+			}
+		})
+	}
+}
+
+func TestWithRetryAttemptTimeout(t *testing.T) {
+	p := testPolicy(1)
+	p.timeout = 10 * time.Millisecond
+
+	calls := 0
+	_, err := withRetry(context.Background(), p, func(ctx context.Context) (int, error) {
+		calls++
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if calls != 2 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("calls = %d, err = %v; want 2 timed-out attempts", calls, err)
+	}
+}
+
+// A cancelled call returns at once with the context's error, which callers
+// take as the signal to save nothing.
+func TestWithRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewRetryPolicy(0, 5)
+	p.baseDelay = time.Hour
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		_, err := withRetry(ctx, p, func(ctx context.Context) (int, error) {
+			calls++
+			return 0, &statusError{code: 503}
+		})
+		done <- err
+	}()
+
+	// The first attempt fails and withRetry waits out its backoff
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+		if calls != 1 {
+			t.Errorf("calls = %d, want no attempt after cancelling", calls)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("withRetry didn't return after the context was cancelled")
+	}
+
+	// An attempt cut short by cancelling isn't retried either
+	ctx, cancel = context.WithCancel(context.Background())
+	calls = 0
+	_, err := withRetry(ctx, testPolicy(5), func(ctx context.Context) (int, error) {
+		calls++
+		cancel()
+		return 0, &statusError{code: 503}
+	})
+	if calls != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("calls = %d, err = %v; want one attempt and context.Canceled", calls, err)
+	}
+}
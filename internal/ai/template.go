@@ -0,0 +1,340 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/config"
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+func init() {
+	Register("template", func(cfg *config.Config) (Planner, error) {
+		return NewTemplatePlanner(cfg.TemplatesDir)
+	})
+}
+
+// PlanTemplate is a canned plan loaded from YAML. Titles may reference
+// {{.Goal}} and {{.Context}}.
+type PlanTemplate struct {
+	Name       string              `yaml:"name"`
+	Match      []string            `yaml:"match"`
+	Milestones []TemplateMilestone `yaml:"milestones"`
+}
+
+type TemplateMilestone struct {
+	Title     string            `yaml:"title"`
+	Rationale string            `yaml:"rationale"`
+	Subtasks  []TemplateSubtask `yaml:"subtasks"`
+}
+
+type TemplateSubtask struct {
+	Title     string `yaml:"title"`
+	Minutes   int    `yaml:"minutes"`
+	Rationale string `yaml:"rationale"`
+}
+
+// defaultSubtaskMins is used for template subtasks without an estimate.
+const defaultSubtaskMins = 25
+
+// TemplatePlanner is a deterministic, offline Planner that builds plans from
+// YAML templates instead of asking an LLM.
+type TemplatePlanner struct {
+	templates []PlanTemplate
+
+	// picked is the name of the template used last, reported as the model.
+	picked string
+}
+
+// NewTemplatePlanner loads the built-in templates plus any *.yaml files in
+// dir. A user template replaces a built-in one with the same name.
+func NewTemplatePlanner(dir string) (*TemplatePlanner, error) {
+	byName := map[string]PlanTemplate{}
+
+	if err := loadTemplates(builtinTemplates, "templates", byName); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if _, err := os.Stat(dir); err == nil {
+			if err := loadTemplates(os.DirFS(dir), ".", byName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, ok := byName["default"]; !ok {
+		return nil, fmt.Errorf("no default plan template found")
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := &TemplatePlanner{}
+	for _, name := range names {
+		p.templates = append(p.templates, byName[name])
+	}
+	return p, nil
+}
+
+func loadTemplates(fsys fs.FS, dir string, byName map[string]PlanTemplate) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read plan template %s: %w", file, err)
+		}
+
+		var t PlanTemplate
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("failed to parse plan template %s: %w", file, err)
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(path.Base(file), ".yaml")
+		}
+		if len(t.Milestones) == 0 {
+			return fmt.Errorf("plan template %s has no milestones", file)
+		}
+		byName[t.Name] = t
+	}
+	return nil
+}
+
+// pick returns the template whose name or match keywords appear in the goal,
+// preferring the one with the most hits, and falls back to "default".
+func (p *TemplatePlanner) pick(goal string, contextInfo string) PlanTemplate {
+	words := strings.Fields(strings.ToLower(goal + " " + contextInfo))
+	has := map[string]bool{}
+	for _, w := range words {
+		has[strings.Trim(w, ".,!?;:\"'()")] = true
+	}
+
+	var best PlanTemplate
+	bestScore := 0
+	for _, t := range p.templates {
+		score := 0
+		if has[strings.ToLower(t.Name)] {
+			score++
+		}
+		for _, m := range t.Match {
+			if has[strings.ToLower(m)] {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+
+	if bestScore == 0 {
+		for _, t := range p.templates {
+			if t.Name == "default" {
+				return t
+			}
+		}
+	}
+	return best
+}
+
+func (p *TemplatePlanner) GenerateHighLevelTasks(ctx context.Context, goal string, contextInfo string) (*Response, error) {
+	t := p.pick(goal, contextInfo)
+	p.picked = t.Name
+	data := templateData{Goal: goal, Context: contextInfo}
+
+	var milestones []PlanItem
+	for _, m := range t.Milestones {
+		title, err := render(m.Title, data)
+		if err != nil {
+			return nil, fmt.Errorf("plan template %s: %w", t.Name, err)
+		}
+
+		subtasks, err := renderSubtasks(m, data)
+		if err != nil {
+			return nil, fmt.Errorf("plan template %s: %w", t.Name, err)
+		}
+		milestone := PlanItem{Title: title, Rationale: m.Rationale}
+		for _, sub := range subtasks {
+			milestone.EstimatedDurationMins += sub.EstimatedDurationMins
+		}
+		milestones = append(milestones, milestone)
+	}
+
+	raw, err := json.Marshal(milestones)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Items: milestones, Raw: string(raw)}, nil
+}
+
+// GenerateSubTasks finds the template milestone the title was rendered from
+// and renders its subtasks with the goal and context read back out of the
+// title. It needs nothing from an earlier GenerateHighLevelTasks call, so
+// resumed plans work too.
+func (p *TemplatePlanner) GenerateSubTasks(ctx context.Context, parentTask string) (*Response, error) {
+	// Titles shared by several templates go to the one picked last, if any
+	templates := slices.Clone(p.templates)
+	if i := slices.IndexFunc(templates, func(t PlanTemplate) bool { return t.Name == p.picked }); i > 0 {
+		picked := templates[i]
+		templates = slices.Insert(slices.Delete(templates, i, i+1), 0, picked)
+	}
+	for _, t := range templates {
+		for _, m := range t.Milestones {
+			data, ok := matchTitle(m.Title, parentTask)
+			if !ok || len(m.Subtasks) == 0 {
+				continue
+			}
+			subtasks, err := renderSubtasks(m, data)
+			if err != nil {
+				return nil, fmt.Errorf("plan template %s: %w", t.Name, err)
+			}
+			raw, err := json.Marshal(subtasks)
+			if err != nil {
+				return nil, err
+			}
+			return &Response{Items: subtasks, Raw: string(raw)}, nil
+		}
+	}
+	return nil, fmt.Errorf("template planner cannot plan custom milestone %q", parentTask)
+}
+
+// templateField matches the {{.Field}} actions milestone titles use.
+var templateField = regexp.MustCompile(`\{\{-?\s*\.(\w+)\s*-?\}\}`)
+
+// matchTitle reports whether title could have been rendered from the
+// template text, and with which goal and context.
+func matchTitle(text, title string) (templateData, bool) {
+	var expr strings.Builder
+	var fields []string
+	last := 0
+	for _, loc := range templateField.FindAllStringSubmatchIndex(text, -1) {
+		expr.WriteString(regexp.QuoteMeta(text[last:loc[0]]))
+		expr.WriteString("(.*)")
+		fields = append(fields, text[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(text[last:]))
+
+	re, err := regexp.Compile("^" + expr.String() + "$")
+	if err != nil {
+		return templateData{}, false
+	}
+	match := re.FindStringSubmatch(title)
+	if match == nil {
+		return templateData{}, false
+	}
+
+	var data templateData
+	for i, field := range fields {
+		switch field {
+		case "Goal":
+			data.Goal = match[i+1]
+		case "Context":
+			data.Context = match[i+1]
+		default:
+			return templateData{}, false
+		}
+	}
+	return data, true
+}
+
+// Replan re-renders the matching template and keeps the milestones whose
+// titles aren't among the completed work.
+func (p *TemplatePlanner) Replan(ctx context.Context, req ReplanRequest) (*Response, error) {
+	resp, err := p.GenerateHighLevelTasks(ctx, req.Goal, req.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[string]bool{}
+	for _, c := range req.Completed {
+		done[c.Title] = true
+	}
+
+	var remaining []PlanItem
+	for _, m := range resp.Items {
+		if !done[m.Title] {
+			remaining = append(remaining, m)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("template has no milestones left to plan")
+	}
+
+	raw, err := json.Marshal(remaining)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Items: remaining, Raw: string(raw)}, nil
+}
+
+// SplitTask always fails: templates only know the tasks they were written
+// with.
+func (p *TemplatePlanner) SplitTask(ctx context.Context, req SplitRequest) (*Response, error) {
+	return nil, fmt.Errorf("the template planner can't split tasks; use an AI provider")
+}
+
+func (p *TemplatePlanner) Info() Info {
+	return Info{Provider: "template", Model: p.picked, PromptVersion: PromptVersion}
+}
+
+func (p *TemplatePlanner) SuggestContent(ctx context.Context, interests []string) (string, error) {
+	if len(interests) == 0 {
+		return "Step away from the screen for fifteen minutes.", nil
+	}
+	topic := interests[time.Now().YearDay()%len(interests)]
+	return fmt.Sprintf("Read one long-form article about %s that has nothing to do with your current task.", topic), nil
+}
+
+// templateData is what template titles can refer to.
+type templateData struct{ Goal, Context string }
+
+// renderSubtasks renders a template milestone's subtasks, filling in missing
+// estimates.
+func renderSubtasks(m TemplateMilestone, data templateData) ([]PlanItem, error) {
+	var subtasks []PlanItem
+	for _, s := range m.Subtasks {
+		title, err := render(s.Title, data)
+		if err != nil {
+			return nil, err
+		}
+		mins := s.Minutes
+		if mins <= 0 {
+			mins = defaultSubtaskMins
+		}
+		subtasks = append(subtasks, PlanItem{Title: title, EstimatedDurationMins: mins, Rationale: s.Rationale})
+	}
+	return subtasks, nil
+}
+
+func render(text string, data any) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
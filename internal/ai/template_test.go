@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateSubTasksWithoutState(t *testing.T) {
+	ctx := context.Background()
+	planned, err := NewTemplatePlanner("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := planned.GenerateHighLevelTasks(ctx, "Learn Spanish", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh planner, as when resuming a plan, finds the same subtasks
+	fresh, err := NewTemplatePlanner("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range resp.Items {
+		want, err := planned.GenerateSubTasks(ctx, m.Title)
+		if err != nil {
+			t.Fatalf("GenerateSubTasks(%q): %v", m.Title, err)
+		}
+		got, err := fresh.GenerateSubTasks(ctx, m.Title)
+		if err != nil {
+			t.Fatalf("fresh GenerateSubTasks(%q): %v", m.Title, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("subtasks of %q = %v, want %v", m.Title, got, want)
+		}
+	}
+
+	_, err = fresh.GenerateSubTasks(ctx, "Something I made up")
+	if err == nil || !strings.Contains(err.Error(), "cannot plan custom milestone") {
+		t.Errorf("custom milestone error = %v", err)
+	}
+}
+
+func TestMatchTitle(t *testing.T) {
+	tests := []struct {
+		text, title string
+		want        templateData
+		ok          bool
+	}{
+		{"Get set up: {{.Goal}}", "Get set up: Learn Go", templateData{Goal: "Learn Go"}, true},
+		{"Get set up: {{ .Goal }}", "Get set up: a.b (c)", templateData{Goal: "a.b (c)"}, true},
+		{"Gather what you need", "Gather what you need", templateData{}, true},
+		{"Get set up: {{.Goal}}", "Get going: Learn Go", templateData{}, false},
+		{"{{.Goal}} for {{.Context}}", "Go for work", templateData{Goal: "Go", Context: "work"}, true},
+	}
+	for _, tt := range tests {
+		got, ok := matchTitle(tt.text, tt.title)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("matchTitle(%q, %q) = %+v, %v; want %+v, %v", tt.text, tt.title, got, ok, tt.want, tt.ok)
+		}
+	}
+}
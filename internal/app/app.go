@@ -1,14 +1,36 @@
 package app
 
 import (
-	"database/sql"
-
 	"github.com/yagnikpt/kairos/internal/ai"
 	"github.com/yagnikpt/kairos/internal/config"
+	"github.com/yagnikpt/kairos/internal/store"
 )
 
 type App struct {
-	DB     *sql.DB
-	AI     *ai.Client
+	Store  *store.Store
 	Config *config.Config
+
+	planners map[string]ai.Planner
+}
+
+// Planner returns the AI provider registered under name, or the configured
+// provider when name is empty. Providers are built on first use so commands
+// that never plan don't need credentials or network access.
+func (a *App) Planner(name string) (ai.Planner, error) {
+	if name == "" {
+		name = a.Config.Provider
+	}
+	if p, ok := a.planners[name]; ok {
+		return p, nil
+	}
+
+	p, err := ai.New(name, a.Config)
+	if err != nil {
+		return nil, err
+	}
+	if a.planners == nil {
+		a.planners = map[string]ai.Planner{}
+	}
+	a.planners[name] = p
+	return p, nil
 }
@@ -1,13 +1,18 @@
 package commands
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/ai"
 	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/tui"
 	"github.com/yagnikpt/kairos/internal/ui"
 )
 
@@ -18,6 +23,7 @@ func newAddCmd(a *app.App) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			var goalName string
 			contextInfo, _ := cmd.Flags().GetString("context")
+			plannerName, _ := cmd.Flags().GetString("planner")
 
 			if len(args) > 0 {
 				goalName = strings.Join(args, " ")
@@ -44,81 +50,80 @@ func newAddCmd(a *app.App) *cobra.Command {
 				return
 			}
 
-			ui.RenderTitle("Analyzing your goal...")
-			highLevelTasks, err := a.AI.GenerateHighLevelTasks(goalName, contextInfo)
+			planner, err := a.Planner(plannerName)
 			if err != nil {
 				ui.RenderError(err)
 				return
 			}
 
-			ui.RenderSubtitle("Proposed milestones:")
-			for _, t := range highLevelTasks {
-				fmt.Printf("- %s\n", t)
-			}
-
-			var confirm bool
-			confirmForm := huh.NewForm(
-				huh.NewGroup(
-					huh.NewConfirm().
-						Title("Do these look good?").
-						Value(&confirm),
-				),
-			)
+			ctx := cmd.Context()
 
-			if err := confirmForm.Run(); err != nil {
+			ui.RenderTitle("Analyzing your goal...")
+			resp, err := planner.GenerateHighLevelTasks(ctx, goalName, contextInfo)
+			if ctx.Err() != nil {
+				ui.RenderSubtitle("Cancelled. Nothing was saved.")
+				return
+			}
+			if err != nil {
 				ui.RenderError(err)
 				return
 			}
+			highLevelTasks := resp.Items
 
-			if !confirm {
-				ui.RenderSubtitle("Cancelled. Try again.")
+			// Generate the whole plan before writing anything, so Ctrl-C or a
+			// failure leaves the database untouched.
+			results, err := tui.RunPlanProgress(ctx, planner, ai.Titles(highLevelTasks), a.Config.PlanConcurrency)
+			if errors.Is(err, context.Canceled) {
+				ui.RenderSubtitle("Cancelled. Nothing was saved.")
+				return
+			} else if err != nil {
+				ui.RenderError(err)
 				return
 			}
 
-			// Save Goal
-			res, err := a.DB.Exec("INSERT INTO goals (name, status, created_at) VALUES (?, 'ACTIVE', ?)", goalName, time.Now())
+			milestones := make([]ai.Milestone, len(highLevelTasks))
+			for i, hlTask := range highLevelTasks {
+				milestones[i] = ai.Milestone{PlanItem: hlTask, Subtasks: results[i].Subtasks, RawResponse: results[i].Raw}
+				if err := results[i].Err; err != nil {
+					milestones[i].NeedsPlanning, milestones[i].PlanningError = true, err.Error()
+				}
+			}
+
+			milestones, ok, err := tui.RunPlanEditor(ctx, planner, goalName, milestones)
 			if err != nil {
 				ui.RenderError(err)
 				return
 			}
-			goalID, _ := res.LastInsertId()
-
-			ui.RenderTitle("Generating detailed plan... (this might take a moment)")
-
-			// Generate and Save Tasks
-			for _, hlTask := range highLevelTasks {
-				// Save High Level Task
-				res, err := a.DB.Exec("INSERT INTO tasks (goal_id, description, status) VALUES (?, ?, 'PENDING')", goalID, hlTask)
-				if err != nil {
-					ui.RenderError(err)
-					continue
-				}
-				hlTaskID, _ := res.LastInsertId()
+			if !ok {
+				ui.RenderSubtitle("Cancelled. Nothing was saved.")
+				return
+			}
 
-				// Generate Subtasks
-				subTasks, err := a.AI.GenerateSubTasks(hlTask)
-				if err != nil {
-					ui.RenderError(fmt.Errorf("failed to generate subtasks for '%s': %v", hlTask, err))
-					continue
+			failed := 0
+			for _, m := range milestones {
+				if m.NeedsPlanning {
+					failed++
 				}
+			}
 
-				for _, subTask := range subTasks {
-					_, err := a.DB.Exec("INSERT INTO tasks (goal_id, parent_task_id, description, status) VALUES (?, ?, ?, 'PENDING')", goalID, hlTaskID, subTask)
-					if err != nil {
-						ui.RenderError(err)
-					}
-				}
+			goal := models.Goal{
+				Name:    goalName,
+				Context: sql.NullString{String: contextInfo, Valid: contextInfo != ""},
 			}
+			planMetadata(&goal, planner, resp)
 
-			// Set as current goal
-			_, err = a.DB.Exec("INSERT OR REPLACE INTO app_state (key, value) VALUES ('current_goal_id', ?)", goalID)
-			if err != nil {
-				ui.RenderError(err)
+			if _, err := a.Store.CreateGoalWithPlan(goal, milestones); err != nil {
+				ui.RenderError(fmt.Errorf("failed to save plan: %w", err))
+				return
 			}
 
+			if failed > 0 {
+				ui.RenderSubtitle(fmt.Sprintf("%d milestone(s) need planning. Run 'kairos plan resume' to retry them.", failed))
+			}
 			ui.RenderSuccess("Goal setup complete! Run 'kairos' to start working.")
 		},
 	}
 	cmd.Flags().StringP("context", "c", "", "Additional context for the goal")
+	cmd.Flags().String("planner", "", "Planner to use instead of the configured provider (e.g. gemini, openai, template)")
 	return cmd
 }
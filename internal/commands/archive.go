@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newArchiveCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "archive <goal>",
+		Short: "Archive a goal",
+		Long: `Hide a goal from 'kairos switch' without deleting it. The goal is given by ID
+or name. Use 'kairos switch --all' to see archived goals and restore them.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			goal, err := findGoal(a, args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if goal.Status == "ARCHIVED" {
+				ui.RenderSubtitle(fmt.Sprintf("'%s' is already archived.", goal.Name))
+				return
+			}
+
+			if err := a.Store.ArchiveGoal(goal.ID); err != nil {
+				ui.RenderError(err)
+				return
+			}
+			ui.RenderSuccess(fmt.Sprintf("Archived '%s'.", goal.Name))
+		},
+	}
+}
@@ -22,7 +22,13 @@ func newChillCmd(a *app.App) *cobra.Command {
 			// Hardcoded interests for now, could be stored in config/DB
 			interests := []string{"Technology", "Science", "Programming", "Hacker News"}
 
-			suggestion, err := a.AI.SuggestContent(interests)
+			planner, err := a.Planner("")
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			suggestion, err := planner.SuggestContent(cmd.Context(), interests)
 			if err != nil {
 				ui.RenderError(err)
 				return
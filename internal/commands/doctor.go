@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newDoctorCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the database for integrity problems",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ui.RenderTitle("Checking " + a.Config.DBPath)
+
+			problems, err := a.Store.CheckIntegrity()
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if len(problems) == 0 {
+				ui.RenderSuccess("No problems found.")
+				return
+			}
+
+			ui.RenderSubtitle(fmt.Sprintf("Found %d problem(s):", len(problems)))
+			for _, p := range problems {
+				fmt.Println(ui.ItemStyle.Render("- " + p))
+			}
+		},
+	}
+}
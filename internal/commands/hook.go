@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/git"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newHookCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Link git commits to your tasks",
+	}
+	cmd.AddCommand(newHookInstallCmd())
+	cmd.AddCommand(newHookPostCommitCmd(a))
+	return cmd
+}
+
+func newHookInstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "install [repo]",
+		Short: "Install a post-commit hook into a git repository",
+		Long: `Install a post-commit hook into a git repository, the current one by default.
+Each commit is then attached as proof of work to the subtask you're working
+on in focus mode, and subtasks named in a trailer are checked off:
+
+    Kairos-Done: 42, 43
+
+Task IDs are shown in the goal tree of focus mode.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			path, err := git.InstallHook(dir, "post-commit", shellQuote(exe)+" hook post-commit")
+			if errors.Is(err, git.ErrForeignHook) {
+				ui.RenderError(fmt.Errorf("%s already exists; add '%s hook post-commit' to it yourself", path, exe))
+				return
+			} else if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			ui.RenderSuccess(fmt.Sprintf("Installed %s.", path))
+		},
+	}
+}
+
+func newHookPostCommitCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:    "post-commit",
+		Short:  "Record the latest commit (run by the git hook)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			commit, err := git.HeadCommit(".")
+			if err != nil {
+				ui.RenderError(fmt.Errorf("kairos: %w", err))
+				return
+			}
+			working, milestoneDone, err := a.Store.AttachCommit(commit)
+			if err != nil {
+				ui.RenderError(fmt.Errorf("kairos: %w", err))
+				return
+			}
+
+			if working != nil && !slices.Contains(commit.Done, working.ID) {
+				ui.RenderSubtitle(fmt.Sprintf("kairos: attached %s to '%s'", commit.Short(), working.Description))
+			}
+			if len(commit.Done) > 0 {
+				var ids []string
+				for _, id := range commit.Done {
+					ids = append(ids, fmt.Sprintf("#%d", id))
+				}
+				ui.RenderSuccess(fmt.Sprintf("kairos: checked off %s", strings.Join(ids, ", ")))
+			}
+			if milestoneDone {
+				ui.RenderSuccess("kairos: milestone completed!")
+			}
+		},
+	}
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
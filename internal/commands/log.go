@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newLogCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the history of changes",
+		Long: `List the latest changes to goals and tasks, newest first, along with undos and
+redos. Changes that are currently undone are marked.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+
+			events, err := a.Store.Events(limit)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if len(events) == 0 {
+				ui.RenderSubtitle("Nothing has happened yet.")
+				return
+			}
+
+			faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+			for _, e := range events {
+				line := e.Summary
+				switch e.Kind {
+				case "UNDO":
+					line = "undo: " + line
+				case "REDO":
+					line = "redo: " + line
+				}
+				if e.Undone {
+					line += " (undone)"
+				}
+
+				prefix := faint.Render(fmt.Sprintf("%4d  %s", e.ID, e.CreatedAt.Local().Format("2006-01-02 15:04")))
+				if e.Kind != "CHANGE" || e.Undone {
+					fmt.Println(prefix + "  " + faint.Render(line))
+				} else {
+					fmt.Println(prefix + "  " + ui.StatusStyle.Render(line))
+				}
+			}
+		},
+	}
+	cmd.Flags().IntP("limit", "n", 20, "How many events to show")
+	return cmd
+}
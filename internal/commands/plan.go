@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/store"
+	"github.com/yagnikpt/kairos/internal/tui"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newPlanCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Manage the plan of a goal",
+	}
+	cmd.AddCommand(newPlanResumeCmd(a))
+	cmd.AddCommand(newPlanInfoCmd(a))
+	return cmd
+}
+
+func newPlanResumeCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume [goal]",
+		Short: "Generate subtasks for milestones that still need planning",
+		Long:  "Generate subtasks for milestones whose planning failed earlier. The goal is given by ID or name and defaults to the current goal.",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			plannerName, _ := cmd.Flags().GetString("planner")
+
+			goal, err := findGoal(a, args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			tasks, err := a.Store.MilestonesNeedingPlanning(goal.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if len(tasks) == 0 {
+				ui.RenderSuccess(fmt.Sprintf("Every milestone of '%s' is already planned.", goal.Name))
+				return
+			}
+
+			var ids []int64
+			var milestones []string
+			for _, t := range tasks {
+				ids = append(ids, t.ID)
+				milestones = append(milestones, t.Description)
+			}
+
+			planner, err := a.Planner(plannerName)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			ui.RenderSubtitle(fmt.Sprintf("Planning %d milestone(s) of '%s'", len(milestones), goal.Name))
+			results, err := tui.RunPlanProgress(ctx, planner, milestones, a.Config.PlanConcurrency)
+			if errors.Is(err, context.Canceled) {
+				ui.RenderSubtitle("Cancelled. Nothing was saved.")
+				return
+			} else if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			for i, r := range results {
+				if r.Err != nil {
+					ui.RenderError(fmt.Errorf("failed to generate subtasks for '%s': %v", milestones[i], r.Err))
+				}
+			}
+
+			planned, err := a.Store.PlanMilestones(goal.ID, ids, results)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			if left := len(milestones) - planned; left > 0 {
+				ui.RenderSubtitle(fmt.Sprintf("Planned %d milestone(s); %d still need planning. Run 'kairos plan resume' again later.", planned, left))
+				return
+			}
+			ui.RenderSuccess("All milestones planned.")
+		},
+	}
+	cmd.Flags().String("planner", "", "Planner to use instead of the configured provider (e.g. gemini, openai, template)")
+	return cmd
+}
+
+func newPlanInfoCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info [goal]",
+		Short: "Show how a goal's plan was generated",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			showRaw, _ := cmd.Flags().GetBool("raw")
+
+			g, err := findGoal(a, args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			orNone := func(s sql.NullString) string {
+				if !s.Valid || s.String == "" {
+					return "-"
+				}
+				return s.String
+			}
+
+			ui.RenderTitle(g.Name)
+			ui.RenderStatus("CREATED:", g.CreatedAt.Format("2006-01-02 15:04"))
+			ui.RenderStatus("CONTEXT:", orNone(g.Context))
+			ui.RenderStatus("PLANNER:", orNone(g.Planner))
+			ui.RenderStatus("MODEL:", orNone(g.Model))
+			if g.PromptVersion.Valid {
+				ui.RenderStatus("PROMPT VERSION:", strconv.FormatInt(g.PromptVersion.Int64, 10))
+			} else {
+				ui.RenderStatus("PROMPT VERSION:", "-")
+			}
+			if !showRaw {
+				return
+			}
+			fmt.Println()
+			ui.RenderSubtitle("Raw response:")
+			fmt.Println(orNone(g.RawResponse))
+
+			tasks, err := a.Store.Tasks(g.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			for _, t := range tasks {
+				if t.RawResponse.Valid {
+					fmt.Println()
+					ui.RenderSubtitle(fmt.Sprintf("Raw response for the subtasks of '%s':", t.Description))
+					fmt.Println(t.RawResponse.String)
+				}
+			}
+		},
+	}
+	cmd.Flags().Bool("raw", false, "Also print the raw planner responses")
+	return cmd
+}
+
+// planMetadata fills a goal's planning metadata from the planner and the
+// response the plan was built from.
+func planMetadata(goal *models.Goal, planner ai.Planner, resp *ai.Response) {
+	info := planner.Info()
+	goal.Planner = sql.NullString{String: info.Provider, Valid: true}
+	goal.Model = sql.NullString{String: info.Model, Valid: info.Model != ""}
+	goal.PromptVersion = sql.NullInt64{Int64: int64(info.PromptVersion), Valid: true}
+	goal.RawResponse = sql.NullString{String: resp.Raw, Valid: true}
+}
+
+// findGoal resolves the optional goal argument shared by commands that act
+// on one goal: an ID or name, defaulting to the active goal.
+func findGoal(a *app.App, args []string) (*models.Goal, error) {
+	ref := strings.Join(args, " ")
+	goal, err := a.Store.FindGoal(ref)
+	switch {
+	case errors.Is(err, store.ErrNoActiveGoal):
+		return nil, fmt.Errorf("no active goal selected; pass a goal ID or name")
+	case errors.Is(err, store.ErrNotFound):
+		return nil, fmt.Errorf("no goal named or numbered %q", ref)
+	}
+	return goal, err
+}
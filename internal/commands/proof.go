@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newProofCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proof [goal]",
+		Short: "Show or set whether a goal requires proof of work",
+		Long: `When a goal requires proof of work, its tasks can only be checked off in focus
+mode with a note, URL, file path or commit attached. Without flags the current
+policy is shown. The goal is given by ID or name and defaults to the current
+goal.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			require, _ := cmd.Flags().GetBool("require")
+			optional, _ := cmd.Flags().GetBool("optional")
+
+			goal, err := findGoal(a, args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			if !require && !optional {
+				if goal.ProofRequired {
+					ui.RenderStatus("PROOF OF WORK:", fmt.Sprintf("required for '%s'", goal.Name))
+				} else {
+					ui.RenderStatus("PROOF OF WORK:", fmt.Sprintf("optional for '%s'", goal.Name))
+				}
+				return
+			}
+
+			if err := a.Store.SetProofRequired(goal.ID, require); err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if require {
+				ui.RenderSuccess(fmt.Sprintf("Tasks of '%s' now need proof of work to be checked off.", goal.Name))
+			} else {
+				ui.RenderSuccess(fmt.Sprintf("Proof of work is now optional for '%s'.", goal.Name))
+			}
+		},
+	}
+	cmd.Flags().Bool("require", false, "Require proof of work to check tasks off")
+	cmd.Flags().Bool("optional", false, "Make proof of work optional")
+	cmd.MarkFlagsMutuallyExclusive("require", "optional")
+	return cmd
+}
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/tui"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newReplanCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replan [goal]",
+		Short: "Regenerate the remaining plan of a goal",
+		Long: `Send the goal, the work finished so far and an optional note back to the planner
+and replace the pending milestones and subtasks with a fresh plan. Finished and
+skipped tasks are kept, and pending tasks with finished work or tracked time
+under them are closed as skipped rather than removed. The goal is given by ID
+or name and defaults to the current goal.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			note, _ := cmd.Flags().GetString("note")
+			contextInfo, _ := cmd.Flags().GetString("context")
+			plannerName, _ := cmd.Flags().GetString("planner")
+
+			goal, err := findGoal(a, args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			goalName := goal.Name
+
+			// Default to the context the goal was planned with
+			if contextInfo != "" {
+				goal.Context = sql.NullString{String: contextInfo, Valid: true}
+			}
+
+			tasks, err := a.Store.Tasks(goal.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			req := ai.ReplanRequest{Goal: goalName, Context: goal.Context.String, Note: note}
+			for _, t := range tasks {
+				if t.Status == "DONE" || t.Status == "SKIPPED" {
+					req.Completed = append(req.Completed, ai.CompletedTask{Title: t.Description, Status: t.Status})
+				}
+			}
+
+			planner, err := a.Planner(plannerName)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			ui.RenderTitle(fmt.Sprintf("Replanning '%s'...", goalName))
+			resp, err := planner.Replan(ctx, req)
+			if ctx.Err() != nil {
+				ui.RenderSubtitle("Cancelled. Nothing was changed.")
+				return
+			}
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			highLevelTasks := resp.Items
+			planMetadata(goal, planner, resp)
+
+			results, err := tui.RunPlanProgress(ctx, planner, ai.Titles(highLevelTasks), a.Config.PlanConcurrency)
+			if errors.Is(err, context.Canceled) {
+				ui.RenderSubtitle("Cancelled. Nothing was changed.")
+				return
+			} else if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			milestones := make([]ai.Milestone, len(highLevelTasks))
+			for i, hlTask := range highLevelTasks {
+				milestones[i] = ai.Milestone{PlanItem: hlTask, Subtasks: results[i].Subtasks, RawResponse: results[i].Raw}
+				if err := results[i].Err; err != nil {
+					milestones[i].NeedsPlanning, milestones[i].PlanningError = true, err.Error()
+				}
+			}
+
+			kept, err := a.Store.ReplanKept(goal.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			renderReplanDiff(tasks, kept, milestones)
+
+			var confirm bool
+			confirmForm := huh.NewForm(
+				huh.NewGroup(
+					huh.NewConfirm().
+						Title("Replace the pending plan with this one?").
+						Value(&confirm),
+				),
+			).WithTheme(ui.HuhTheme)
+
+			if err := confirmForm.Run(); err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if !confirm {
+				ui.RenderSubtitle("Cancelled. Nothing was changed.")
+				return
+			}
+
+			if err := a.Store.ReplacePendingPlan(*goal, milestones); err != nil {
+				ui.RenderError(fmt.Errorf("failed to save plan: %w", err))
+				return
+			}
+			ui.RenderSuccess("Plan updated. Run 'kairos' to keep going.")
+		},
+	}
+	cmd.Flags().StringP("note", "n", "", "What changed since the plan was made")
+	cmd.Flags().StringP("context", "c", "", "Replace the goal's stored context")
+	cmd.Flags().String("planner", "", "Planner to use instead of the configured provider (e.g. gemini, openai, template)")
+	return cmd
+}
+
+func isPending(status string) bool {
+	return status == "PENDING" || status == "IN_PROGRESS"
+}
+
+// renderReplanDiff shows what a replan changes. Pending tasks in kept have
+// finished work or recorded time under them and are closed as skipped
+// instead of removed.
+func renderReplanDiff(tasks []models.Task, kept map[int64]bool, milestones []ai.Milestone) {
+	removed := lipgloss.NewStyle().Foreground(ui.AccentColor)
+	closed := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+	added := lipgloss.NewStyle().Foreground(ui.SecondaryColor)
+
+	// Tasks come in plan order, so a parent's depth is known before its
+	// subtasks'.
+	depth := map[int64]int{}
+	ui.RenderSubtitle("Pending tasks that will be replaced:")
+	found := false
+	for _, t := range tasks {
+		if t.ParentTaskID.Valid {
+			depth[t.ID] = depth[t.ParentTaskID.Int64] + 1
+		}
+		if !isPending(t.Status) {
+			continue
+		}
+		found = true
+		indent := strings.Repeat("    ", depth[t.ID])
+		if kept[t.ID] {
+			fmt.Println(closed.Render(indent + "~ " + t.Description + " (skipped as replanned; keeps its finished work and time)"))
+		} else {
+			fmt.Println(removed.Render(indent + "- " + t.Description))
+		}
+	}
+	if !found {
+		fmt.Println(ui.ItemStyle.Render("(none)"))
+	}
+	fmt.Println()
+
+	ui.RenderSubtitle("New plan:")
+	for _, m := range milestones {
+		fmt.Println(added.Render(fmt.Sprintf("+ %s (~%s)", m.Title, ui.FormatDuration(m.EstimatedDurationMins))))
+		if m.NeedsPlanning {
+			fmt.Println(ui.ItemStyle.Render("(needs planning: " + m.PlanningError + ")"))
+		}
+		for _, sub := range m.Subtasks {
+			fmt.Println(added.Render("    + " + sub.Title))
+		}
+	}
+	fmt.Println()
+}
@@ -1,12 +1,11 @@
 package commands
 
 import (
-	"database/sql"
-	"fmt"
-	"strconv"
+	"errors"
 
 	"github.com/spf13/cobra"
 	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/store"
 	"github.com/yagnikpt/kairos/internal/tui"
 	"github.com/yagnikpt/kairos/internal/ui"
 )
@@ -18,9 +17,8 @@ func NewRootCmd(a *app.App) *cobra.Command {
 		Long:  `Kairos is a CLI tool to help you manage your goals and stay focused.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Check for current goal
-			var currentGoalIDStr string
-			err := a.DB.QueryRow("SELECT value FROM app_state WHERE key = ?", "current_goal_id").Scan(&currentGoalIDStr)
-			if err == sql.ErrNoRows {
+			goal, err := a.Store.ActiveGoal()
+			if errors.Is(err, store.ErrNoActiveGoal) {
 				ui.RenderSubtitle("No active goal selected. Use 'kairos add' to start or 'kairos switch' to pick one.")
 				return
 			} else if err != nil {
@@ -28,18 +26,13 @@ func NewRootCmd(a *app.App) *cobra.Command {
 				return
 			}
 
-			currentGoalID, _ := strconv.ParseInt(currentGoalIDStr, 10, 64)
-			if err := tui.RunFocusMode(a, currentGoalID); err != nil {
-				if err == sql.ErrNoRows {
-					ui.RenderSubtitle("No active goal selected. Use 'kairos add' to start or 'kairos switch' to pick one.")
-					// Clean up invalid state
-					a.DB.Exec("DELETE FROM app_state WHERE key = 'current_goal_id'")
-				} else if err.Error() == "user aborted" {
-					fmt.Print("\033[H\033[2J")
-					fmt.Println("Keep grinding 💪")
-				} else {
-					ui.RenderError(err)
-				}
+			err = tui.RunFocusMode(a, goal.ID)
+			// Leaving focus mode ends the work session
+			if stopErr := a.Store.StopSession(); stopErr != nil {
+				ui.RenderError(stopErr)
+			}
+			if err != nil {
+				ui.RenderError(err)
 				return
 			}
 		},
@@ -47,7 +40,19 @@ func NewRootCmd(a *app.App) *cobra.Command {
 
 	cmd.AddCommand(newAddCmd(a))
 	cmd.AddCommand(newSwitchCmd(a))
+	cmd.AddCommand(newArchiveCmd(a))
 	cmd.AddCommand(newChillCmd(a))
+	cmd.AddCommand(newPlanCmd(a))
+	cmd.AddCommand(newReplanCmd(a))
+	cmd.AddCommand(newTimeCmd(a))
+	cmd.AddCommand(newUndoCmd(a))
+	cmd.AddCommand(newRedoCmd(a))
+	cmd.AddCommand(newLogCmd(a))
+	cmd.AddCommand(newTaskCmd(a))
+	cmd.AddCommand(newShowCmd(a))
+	cmd.AddCommand(newProofCmd(a))
+	cmd.AddCommand(newHookCmd(a))
+	cmd.AddCommand(newDoctorCmd(a))
 
 	return cmd
 }
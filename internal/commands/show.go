@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/store"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newShowCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <task>",
+		Short: "Show a task and its proof of work",
+		Long: `Show a task by ID (as listed in the goal tree of focus mode): its goal,
+status, time spent and the proof of work attached when it was checked off.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := findTask(a, args[0])
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			goal, err := a.Store.Goal(task.GoalID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			spent, err := a.Store.TimeSpent(goal.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			ui.RenderTitle(fmt.Sprintf("#%d %s", task.ID, task.Description))
+			ui.RenderStatus("GOAL:", goal.Name)
+			// The milestone, then the task it was split from if that's another
+			var parents []string
+			for parent := task.ParentTaskID; parent.Valid; {
+				t, err := a.Store.Task(parent.Int64)
+				if err != nil {
+					ui.RenderError(err)
+					return
+				}
+				parents = append(parents, t.Description)
+				parent = t.ParentTaskID
+			}
+			if len(parents) > 0 {
+				ui.RenderStatus("MILESTONE:", parents[len(parents)-1])
+			}
+			if len(parents) > 1 {
+				ui.RenderStatus("SPLIT FROM:", parents[0])
+			}
+			ui.RenderStatus("STATUS:", statusMark(task.Status)+" "+task.Status)
+			if task.SkipReason.Valid {
+				ui.RenderStatus("SKIPPED BECAUSE:", task.SkipReason.String)
+			}
+			ui.RenderStatus("TIME:", fmt.Sprintf("%s estimated, %s spent", estimate(*task), spentLabel(spent[task.ID])))
+
+			fmt.Println()
+			entries := store.ProofEntries(task)
+			if len(entries) == 0 {
+				msg := "No proof of work attached."
+				if goal.ProofRequired {
+					msg += " This goal requires it to check tasks off."
+				}
+				ui.RenderSubtitle(msg)
+				return
+			}
+			ui.RenderSubtitle("PROOF OF WORK:")
+			faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+			for _, e := range entries {
+				kind, text := proofKind(e)
+				fmt.Printf("  %s %s\n", faint.Render(fmt.Sprintf("%-6s", kind)), text)
+			}
+		},
+	}
+}
+
+// findTask looks a task up by its ID, with or without a leading '#'.
+func findTask(a *app.App, ref string) (*models.Task, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(ref, "#"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("tasks are given by ID, not %q", ref)
+	}
+	task, err := a.Store.Task(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("no task numbered %d", id)
+	}
+	return task, err
+}
+
+// proofKind tells what a proof of work entry is, for display.
+func proofKind(entry string) (kind, text string) {
+	switch {
+	case strings.HasPrefix(entry, "commit "):
+		return "commit", strings.TrimPrefix(entry, "commit ")
+	case strings.HasPrefix(entry, "http://"), strings.HasPrefix(entry, "https://"):
+		return "url", entry
+	case strings.HasPrefix(entry, "/"):
+		if _, err := os.Stat(entry); err != nil {
+			return "file", entry + " (missing)"
+		}
+		return "file", entry
+	}
+	return "note", entry
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"github.com/yagnikpt/kairos/internal/app"
 	"github.com/yagnikpt/kairos/internal/ui"
@@ -22,11 +23,16 @@ func (i goalItem) Description() string { return i.status }
 func (i goalItem) FilterValue() string { return i.name }
 
 type listKeyMap struct {
-	delete key.Binding
+	archive key.Binding
+	delete  key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
 	return &listKeyMap{
+		archive: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "archive/restore"),
+		),
 		delete: key.NewBinding(
 			key.WithKeys("d"),
 			key.WithHelp("d", "delete"),
@@ -39,6 +45,12 @@ type model struct {
 	keys   *listKeyMap
 	choice *goalItem
 	app    *app.App
+
+	showArchived bool
+	// confirmDelete holds the goal waiting for a y/N answer before it's
+	// deleted for good
+	confirmDelete *goalItem
+	err           error
 }
 
 func (m model) Init() tea.Cmd {
@@ -52,27 +64,52 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.confirmDelete != nil {
+			return m.confirmDeleteKey(msg)
+		}
+		// Let the filter input have every key while typing
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
 		switch {
-		case key.Matches(msg, m.keys.delete):
+		case key.Matches(msg, m.keys.archive):
 			if len(m.list.Items()) == 0 {
 				return m, nil
 			}
 			selectedItem := m.list.SelectedItem().(goalItem)
+			index := m.list.Index()
 
-			// Delete from DB
-			// Note: We are doing this synchronously for simplicity in this CLI tool.
-			// For a larger app, we might want to use a Cmd.
-			_, err := m.app.DB.Exec("DELETE FROM goals WHERE id = ?", selectedItem.id)
-			if err != nil {
-				// In a real app we might want to show an error message
-				return m, nil
+			if selectedItem.status == "ARCHIVED" {
+				if m.err = m.app.Store.RestoreGoal(selectedItem.id); m.err != nil {
+					return m, nil
+				}
+				goal, err := m.app.Store.Goal(selectedItem.id)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				selectedItem.status = goal.Status
+				return m, m.list.SetItem(index, selectedItem)
 			}
 
-			// Remove from list
-			index := m.list.Index()
+			if m.err = m.app.Store.ArchiveGoal(selectedItem.id); m.err != nil {
+				return m, nil
+			}
+			if m.showArchived {
+				selectedItem.status = "ARCHIVED"
+				return m, m.list.SetItem(index, selectedItem)
+			}
 			m.list.RemoveItem(index)
+			return m, nil
 
-			// If list is empty after delete, we might want to show a message or just stay empty
+		case key.Matches(msg, m.keys.delete):
+			if len(m.list.Items()) == 0 {
+				return m, nil
+			}
+			selectedItem := m.list.SelectedItem().(goalItem)
+			m.confirmDelete = &selectedItem
+			m.err = nil
 			return m, nil
 
 		case msg.String() == "enter":
@@ -91,34 +128,63 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// confirmDeleteKey handles the y/N answer to a pending delete. Anything but
+// y cancels.
+func (m model) confirmDeleteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	goal := m.confirmDelete
+	m.confirmDelete = nil
+	if msg.String() != "y" && msg.String() != "Y" {
+		return m, nil
+	}
+
+	// Note: We are doing this synchronously for simplicity in this CLI tool.
+	if m.err = m.app.Store.DeleteGoal(goal.id); m.err != nil {
+		return m, nil
+	}
+	m.list.RemoveItem(m.list.Index())
+	return m, nil
+}
+
 func (m model) View() string {
-	return ui.BoxStyle.Render(m.list.View())
+	warn := lipgloss.NewStyle().Foreground(ui.AccentColor)
+	view := m.list.View()
+	switch {
+	case m.confirmDelete != nil:
+		view += "\n" + warn.Render(fmt.Sprintf("Delete '%s' and all its tasks for good? (y/N)", m.confirmDelete.name))
+	case m.err != nil:
+		view += "\n" + warn.Render(m.err.Error())
+	}
+	return ui.BoxStyle.Render(view)
 }
 
 func newSwitchCmd(a *app.App) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "switch",
 		Short: "Switch to a different goal",
+		Long: `Pick the goal to focus on. Archived goals are hidden unless --all is given;
+selecting one restores it.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			rows, err := a.DB.Query("SELECT id, name, status FROM goals")
+			showArchived, _ := cmd.Flags().GetBool("all")
+
+			goals, err := a.Store.Goals()
 			if err != nil {
 				ui.RenderError(err)
 				return
 			}
-			defer rows.Close()
 
 			var items []list.Item
-			for rows.Next() {
-				var id int64
-				var name, status string
-				if err := rows.Scan(&id, &name, &status); err != nil {
+			for _, g := range goals {
+				if g.Status == "ARCHIVED" && !showArchived {
 					continue
 				}
-
-				items = append(items, goalItem{id: id, name: name, status: status})
+				items = append(items, goalItem{id: g.ID, name: g.Name, status: g.Status})
 			}
 
 			if len(items) == 0 {
+				if len(goals) > 0 {
+					ui.RenderSubtitle("Every goal is archived. Use 'kairos switch --all' to restore one.")
+					return
+				}
 				ui.RenderSubtitle("No goals found. Use 'kairos add' to create one.")
 				return
 			}
@@ -131,25 +197,15 @@ func newSwitchCmd(a *app.App) *cobra.Command {
 			l := list.New(items, delegate, 0, 0)
 			l.Title = "Select a Goal"
 			l.Styles.Title = ui.TitleStyle
+			keys := newListKeyMap()
 			l.AdditionalFullHelpKeys = func() []key.Binding {
-				return []key.Binding{
-					key.NewBinding(
-						key.WithKeys("d"),
-						key.WithHelp("d", "delete"),
-					),
-				}
+				return []key.Binding{keys.archive, keys.delete}
 			}
 			l.AdditionalShortHelpKeys = func() []key.Binding {
-				return []key.Binding{
-					key.NewBinding(
-						key.WithKeys("d"),
-						key.WithHelp("d", "delete"),
-					),
-				}
+				return []key.Binding{keys.archive, keys.delete}
 			}
 
-			keys := newListKeyMap()
-			m := model{list: l, keys: keys, app: a}
+			m := model{list: l, keys: keys, app: a, showArchived: showArchived}
 
 			p := tea.NewProgram(m, tea.WithAltScreen())
 			finalModel, err := p.Run()
@@ -162,20 +218,15 @@ func newSwitchCmd(a *app.App) *cobra.Command {
 			if m, ok := finalModel.(model); ok && m.choice != nil {
 				selectedGoalID := m.choice.id
 
-				// Update DB
-				_, err = a.DB.Exec("INSERT OR REPLACE INTO app_state (key, value) VALUES ('current_goal_id', ?)", selectedGoalID)
-				if err != nil {
+				if err := a.Store.SetActiveGoal(selectedGoalID); err != nil {
 					ui.RenderError(err)
 					return
 				}
 
-				tx, _ := a.DB.Begin()
-				tx.Exec("UPDATE goals SET status = 'IDLE'")
-				tx.Exec("UPDATE goals SET status = 'ACTIVE' WHERE id = ?", selectedGoalID)
-				tx.Commit()
-
 				ui.RenderSuccess(fmt.Sprintf("Switched to: %s", m.choice.name))
 			}
 		},
 	}
+	cmd.Flags().Bool("all", false, "Also show archived goals")
+	return cmd
 }
@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newTaskCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task",
+		Short: "Add, edit, delete, reorder and split milestones and subtasks",
+	}
+	cmd.AddCommand(newTaskAddCmd(a))
+	cmd.AddCommand(newTaskEditCmd(a))
+	cmd.AddCommand(newTaskRmCmd(a))
+	cmd.AddCommand(newTaskMoveCmd(a))
+	cmd.AddCommand(newTaskSplitCmd(a))
+	return cmd
+}
+
+func newTaskAddCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <description>",
+		Short: "Add a milestone, or a subtask with --parent",
+		Long: `Add a task at the end of its list. Without --parent it's a new milestone of
+the goal given with --goal (ID or name, the current goal by default); with
+--parent it's a subtask of that task.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			parent, _ := cmd.Flags().GetString("parent")
+			goalRef, _ := cmd.Flags().GetString("goal")
+			est, _ := cmd.Flags().GetDuration("est")
+			description := strings.Join(args, " ")
+
+			if parent != "" {
+				task, err := findTask(a, parent)
+				if err != nil {
+					ui.RenderError(err)
+					return
+				}
+				id, err := a.Store.AddSubtask(task.ID, description, int(est.Minutes()))
+				if err != nil {
+					ui.RenderError(err)
+					return
+				}
+				ui.RenderSuccess(fmt.Sprintf("Added #%d '%s' to '%s'.", id, description, task.Description))
+				return
+			}
+
+			var goalArgs []string
+			if goalRef != "" {
+				goalArgs = []string{goalRef}
+			}
+			goal, err := findGoal(a, goalArgs)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			id, err := a.Store.AddMilestone(goal.ID, description, int(est.Minutes()))
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			ui.RenderSuccess(fmt.Sprintf("Added milestone #%d '%s' to '%s'.", id, description, goal.Name))
+		},
+	}
+	cmd.Flags().StringP("parent", "p", "", "Task to add a subtask to")
+	cmd.Flags().StringP("goal", "g", "", "Goal to add a milestone to")
+	cmd.Flags().Duration("est", 0, "Time estimate, e.g. 30m or 1h30m")
+	cmd.MarkFlagsMutuallyExclusive("parent", "goal")
+	return cmd
+}
+
+func newTaskEditCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit <task> [description]",
+		Short: "Change a task's description or estimate",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			est, _ := cmd.Flags().GetDuration("est")
+
+			task, err := findTask(a, args[0])
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			description := strings.Join(args[1:], " ")
+			estimate := sql.NullInt64{Int64: int64(est.Minutes()), Valid: cmd.Flags().Changed("est")}
+			if description == "" && !estimate.Valid {
+				ui.RenderError(fmt.Errorf("nothing to change; give a new description or --est"))
+				return
+			}
+
+			if err := a.Store.EditTask(task.ID, description, estimate); err != nil {
+				ui.RenderError(err)
+				return
+			}
+			ui.RenderSuccess(fmt.Sprintf("Updated #%d.", task.ID))
+		},
+	}
+	cmd.Flags().Duration("est", 0, "New time estimate, e.g. 30m; 0 clears it")
+	return cmd
+}
+
+func newTaskRmCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm <task>",
+		Short: "Delete a task along with its subtasks",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			yes, _ := cmd.Flags().GetBool("yes")
+
+			task, err := findTask(a, args[0])
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			if !yes {
+				subtasks, err := a.Store.Subtasks(task.ID)
+				if err != nil {
+					ui.RenderError(err)
+					return
+				}
+				title := fmt.Sprintf("Delete '%s'?", task.Description)
+				if len(subtasks) > 0 {
+					title = fmt.Sprintf("Delete '%s' and everything under it?", task.Description)
+				}
+
+				var confirm bool
+				confirmForm := huh.NewForm(
+					huh.NewGroup(
+						huh.NewConfirm().
+							Title(title).
+							Value(&confirm),
+					),
+				).WithTheme(ui.HuhTheme)
+
+				if err := confirmForm.Run(); err != nil {
+					ui.RenderError(err)
+					return
+				}
+				if !confirm {
+					ui.RenderSubtitle("Cancelled. Nothing was changed.")
+					return
+				}
+			}
+
+			if err := a.Store.DeleteTask(task.ID); err != nil {
+				ui.RenderError(err)
+				return
+			}
+			ui.RenderSuccess(fmt.Sprintf("Deleted '%s'. 'kairos undo' brings it back.", task.Description))
+		},
+	}
+	cmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+	return cmd
+}
+
+func newTaskMoveCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "move <task> <position>",
+		Short: "Move a task to a position among its siblings",
+		Long:  "Move a task to a position (1 for first) among the milestones of its goal or the subtasks of its parent.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			task, err := findTask(a, args[0])
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			position, err := strconv.Atoi(args[1])
+			if err != nil || position < 1 {
+				ui.RenderError(fmt.Errorf("positions start at 1, not %q", args[1]))
+				return
+			}
+
+			if err := a.Store.MoveTask(task.ID, position-1); err != nil {
+				ui.RenderError(err)
+				return
+			}
+			ui.RenderSuccess(fmt.Sprintf("Moved '%s'.", task.Description))
+		},
+	}
+}
+
+func newTaskSplitCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "split <task>",
+		Short: "Break a task down into smaller subtasks",
+		Long: `Ask the planner to break a task that turned out bigger than planned into
+smaller subtasks, with its milestone and goal as context. The subtasks are
+saved under the task, which is then finished through them.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			plannerName, _ := cmd.Flags().GetString("planner")
+
+			task, err := findTask(a, args[0])
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			// Refused by the store too, but checked before asking the planner
+			children, err := a.Store.Subtasks(task.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			if !task.ParentTaskID.Valid || len(children) > 0 {
+				ui.RenderError(fmt.Errorf("'%s' has or needs subtasks of its own; split those instead", task.Description))
+				return
+			}
+			goal, err := a.Store.Goal(task.GoalID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			req := ai.SplitRequest{
+				Goal:          goal.Name,
+				Context:       goal.Context.String,
+				Task:          task.Description,
+				EstimatedMins: int(task.EstimatedDurationMins.Int64),
+			}
+			for parent := task.ParentTaskID; parent.Valid; {
+				t, err := a.Store.Task(parent.Int64)
+				if err != nil {
+					ui.RenderError(err)
+					return
+				}
+				req.Parents = append([]string{t.Description}, req.Parents...)
+				parent = t.ParentTaskID
+			}
+
+			planner, err := a.Planner(plannerName)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			ui.RenderTitle(fmt.Sprintf("Splitting '%s'...", task.Description))
+			resp, err := planner.SplitTask(ctx, req)
+			if ctx.Err() != nil {
+				ui.RenderSubtitle("Cancelled. Nothing was changed.")
+				return
+			}
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			ids, err := a.Store.SplitTask(task.ID, resp)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+			for i, sub := range resp.Items {
+				fmt.Printf("  [ ] %s %s\n", sub.Title, faint.Render(fmt.Sprintf("(%s)  #%d", ui.FormatDuration(sub.EstimatedDurationMins), ids[i])))
+			}
+			ui.RenderSuccess(fmt.Sprintf("Split '%s' into %d subtasks.", task.Description, len(ids)))
+		},
+	}
+	cmd.Flags().String("planner", "", "Planner to use instead of the configured provider (e.g. gemini, openai, template)")
+	return cmd
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newTimeCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "time [goal]",
+		Short: "Compare estimated and actual time",
+		Long: `List every milestone and subtask of a goal with the planner's estimate next to
+the time actually spent on it in focus mode. The goal is given by ID or name
+and defaults to the current goal.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			goal, err := findGoal(a, args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			tasks, err := a.Store.Tasks(goal.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			spent, err := a.Store.TimeSpent(goal.ID)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+
+			type row struct {
+				name, est, spent string
+			}
+			var rows []row
+			var totalEst int
+			var totalSpent time.Duration
+			// Tasks come in plan order, each one after its parent
+			depth := map[int64]int{}
+			for _, t := range tasks {
+				if !t.ParentTaskID.Valid {
+					rows = append(rows, row{t.Description, estimate(t), spentLabel(spent[t.ID])})
+					totalEst += int(t.EstimatedDurationMins.Int64)
+					totalSpent += spent[t.ID]
+					continue
+				}
+				depth[t.ID] = depth[t.ParentTaskID.Int64] + 1
+				indent := strings.Repeat("  ", depth[t.ID])
+				rows = append(rows, row{indent + statusMark(t.Status) + " " + t.Description, estimate(t), spentLabel(spent[t.ID])})
+			}
+			rows = append(rows, row{"Total", ui.FormatDuration(totalEst), spentLabel(totalSpent)})
+
+			width := 0
+			for _, r := range rows {
+				width = max(width, len([]rune(r.name)))
+			}
+
+			ui.RenderTitle(goal.Name)
+			fmt.Println(ui.SubtitleStyle.Render(fmt.Sprintf("%-*s  %8s  %8s", width, "", "EST", "SPENT")))
+			for i, r := range rows {
+				line := fmt.Sprintf("%s%s  %8s  %8s", r.name, strings.Repeat(" ", width-len([]rune(r.name))), r.est, r.spent)
+				if i == len(rows)-1 {
+					fmt.Println(ui.StatusStyle.Render(line))
+				} else {
+					fmt.Println(line)
+				}
+			}
+		},
+	}
+}
+
+func estimate(t models.Task) string {
+	if !t.EstimatedDurationMins.Valid {
+		return "-"
+	}
+	return ui.FormatDuration(int(t.EstimatedDurationMins.Int64))
+}
+
+func spentLabel(d time.Duration) string {
+	if d < time.Minute {
+		return "-"
+	}
+	return ui.FormatDuration(int(d.Minutes()))
+}
+
+func statusMark(status string) string {
+	switch status {
+	case "DONE":
+		return "[x]"
+	case "SKIPPED":
+		return "[-]"
+	default:
+		return "[ ]"
+	}
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+func newUndoCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [n]",
+		Short: "Undo the last n changes (default 1)",
+		Long: `Revert the last n changes to goals and tasks, newest first, in one go. If any
+of them can't be reverted because the data changed since, nothing is undone.
+See 'kairos log' for what would be undone.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := countArg(args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			events, err := a.Store.Undo(n)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			renderReplayed("Undid", "Nothing to undo.", events)
+		},
+	}
+}
+
+func newRedoCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo [n]",
+		Short: "Redo the last n undone changes (default 1)",
+		Long: `Reapply changes reverted by 'kairos undo', most recently undone first. Making
+a new change clears what can be redone.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := countArg(args)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			events, err := a.Store.Redo(n)
+			if err != nil {
+				ui.RenderError(err)
+				return
+			}
+			renderReplayed("Redid", "Nothing to redo.", events)
+		},
+	}
+}
+
+func countArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("expected a positive number of changes, got %q", args[0])
+	}
+	return n, nil
+}
+
+func renderReplayed(verb, none string, events []models.Event) {
+	if len(events) == 0 {
+		ui.RenderSubtitle(none)
+		return
+	}
+	for _, e := range events {
+		ui.RenderSuccess(fmt.Sprintf("%s: %s", verb, e.Summary))
+	}
+}
@@ -6,13 +6,44 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Default pomodoro cycle, also used in place of lengths configured as zero
+// or less.
+const (
+	DefaultPomodoroWork       = 25 * time.Minute
+	DefaultPomodoroShortBreak = 5 * time.Minute
+	DefaultPomodoroLongBreak  = 15 * time.Minute
+)
+
 type Config struct {
 	DBPath       string `mapstructure:"db_path"`
+	Provider     string `mapstructure:"provider"` // AI provider used for planning, e.g. "gemini"
+	Model        string `mapstructure:"model"`    // Optional model override for the provider
 	GeminiAPIKey string `mapstructure:"gemini_api_key"`
+
+	AITimeout    time.Duration `mapstructure:"ai_timeout"`     // Per-attempt deadline for AI calls
+	AIMaxRetries int           `mapstructure:"ai_max_retries"` // Retries on rate limits and server errors
+
+	PlanConcurrency int `mapstructure:"plan_concurrency"` // Subtask generations in flight at once
+
+	// OpenAI-compatible endpoint (Ollama, llama.cpp server, vLLM, ...)
+	OpenAIBaseURL string `mapstructure:"openai_base_url"`
+	OpenAIAPIKey  string `mapstructure:"openai_api_key"`
+
+	// Directory with YAML plan templates for the offline "template" provider
+	TemplatesDir string `mapstructure:"templates_dir"`
+
+	// Pomodoro cycle in focus mode
+	PomodoroWork           time.Duration `mapstructure:"pomodoro_work"`
+	PomodoroShortBreak     time.Duration `mapstructure:"pomodoro_short_break"`
+	PomodoroLongBreak      time.Duration `mapstructure:"pomodoro_long_break"`
+	PomodoroLongBreakEvery int           `mapstructure:"pomodoro_long_break_every"` // Work intervals per long break
+
+	dir string
 }
 
 func Load() (*Config, error) {
@@ -38,7 +69,21 @@ func Load() (*Config, error) {
 	// Default DB path in ~/.local/share/kairos
 	localSharePath := filepath.Join(home, ".local", "share", "kairos")
 	viper.SetDefault("db_path", filepath.Join(localSharePath, "kairos.db"))
+	viper.SetDefault("provider", "gemini")
+	viper.BindEnv("provider", "KAIROS_PROVIDER")
+	viper.BindEnv("model", "KAIROS_MODEL")
 	viper.BindEnv("gemini_api_key", "GEMINI_API_KEY")
+	viper.SetDefault("ai_timeout", 60*time.Second)
+	viper.SetDefault("ai_max_retries", 3)
+	viper.SetDefault("plan_concurrency", 3)
+	viper.SetDefault("openai_base_url", "http://localhost:11434/v1")
+	viper.BindEnv("openai_base_url", "OPENAI_BASE_URL")
+	viper.BindEnv("openai_api_key", "OPENAI_API_KEY")
+	viper.SetDefault("templates_dir", filepath.Join(configPath, "templates"))
+	viper.SetDefault("pomodoro_work", DefaultPomodoroWork)
+	viper.SetDefault("pomodoro_short_break", DefaultPomodoroShortBreak)
+	viper.SetDefault("pomodoro_long_break", DefaultPomodoroLongBreak)
+	viper.SetDefault("pomodoro_long_break_every", 4)
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -54,22 +99,31 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("unable to decode into struct: %w", err)
 	}
 
-	if cfg.GeminiAPIKey == "" {
-		fmt.Print("Gemini API Key not found. Please enter it: ")
-		reader := bufio.NewReader(os.Stdin)
-		key, err := reader.ReadString('\n')
-		if err != nil {
-			return nil, fmt.Errorf("failed to read input: %w", err)
-		}
-		key = strings.TrimSpace(key)
-		cfg.GeminiAPIKey = key
-		viper.Set("gemini_api_key", key)
+	cfg.dir = configPath
 
-		if err := viper.WriteConfigAs(filepath.Join(configPath, "config.yaml")); err != nil {
-			return nil, fmt.Errorf("failed to save config: %w", err)
-		}
-		fmt.Print("\033[H\033[2J")
+	return &cfg, nil
+}
+
+// PromptGeminiKey asks for a Gemini API key on stdin and saves it to the
+// config file. It is only needed once the Gemini provider is actually used,
+// so offline providers never trigger it.
+func (c *Config) PromptGeminiKey() error {
+	fmt.Print("Gemini API Key not found. Please enter it: ")
+	reader := bufio.NewReader(os.Stdin)
+	key, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("no Gemini API key given")
 	}
+	c.GeminiAPIKey = key
+	viper.Set("gemini_api_key", key)
 
-	return &cfg, nil
+	if err := viper.WriteConfigAs(filepath.Join(c.dir, "config.yaml")); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Print("\033[H\033[2J")
+	return nil
 }
@@ -3,31 +3,102 @@ package database
 import (
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"time"
 
-	_ "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/go-sqlite"
 	"github.com/pressly/goose/v3"
 )
 
+// MemoryPath opens a private in-memory database, e.g. for tests.
+const MemoryPath = ":memory:"
+
+// busyTimeout is how long a connection waits for another process to release
+// its write lock before giving up with ErrBusy.
+var busyTimeout = 5 * time.Second
+
+// SQLite primary result codes for lock contention
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// ErrBusy is returned when another kairos process held the database's write
+// lock for longer than the busy timeout.
+var ErrBusy = errors.New("another kairos process is writing to the database; try again in a moment")
+
+// pragmas are applied by the driver to every new connection; per-connection
+// settings like foreign_keys would otherwise be lost whenever the pool opens
+// another one.
+var pragmas = []string{
+	"foreign_keys(1)",
+}
+
+// filePragmas only apply to databases on disk. WAL lets a focus session keep
+// reading while another pane writes.
+var filePragmas = []string{
+	"journal_mode(WAL)",
+	"synchronous(NORMAL)",
+}
+
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
+func dsn(dbPath string) string {
+	q := url.Values{}
+	for _, p := range pragmas {
+		q.Add("_pragma", p)
+	}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	if dbPath != MemoryPath {
+		for _, p := range filePragmas {
+			q.Add("_pragma", p)
+		}
+	}
+	// Take the write lock when a transaction begins rather than on its first
+	// write, so two processes can't both read and then deadlock upgrading.
+	q.Set("_txlock", "immediate")
+	return dbPath + "?" + q.Encode()
+}
+
+// CheckBusy maps SQLite's busy and locked errors to ErrBusy and returns any
+// other error unchanged.
+func CheckBusy(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// Extended result codes keep the primary code in the low byte
+		switch sqliteErr.Code() & 0xff {
+		case sqliteBusy, sqliteLocked:
+			return ErrBusy
+		}
+	}
+	return err
+}
+
 func InitDB(dbPath string) (*sql.DB, error) {
-	// Ensure directory exists
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	if dbPath != MemoryPath {
+		// Ensure directory exists
+		dir := filepath.Dir(dbPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dsn(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	if dbPath == MemoryPath {
+		// Every connection to :memory: is a separate database
+		db.SetMaxOpenConns(1)
+	}
 
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", CheckBusy(err))
 	}
 
 	// Run migrations
@@ -39,7 +110,7 @@ func InitDB(dbPath string) (*sql.DB, error) {
 	}
 
 	if err := goose.Up(db, "migrations"); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+		return nil, fmt.Errorf("failed to run migrations: %w", CheckBusy(err))
 	}
 
 	return db, nil
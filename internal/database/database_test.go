@@ -0,0 +1,72 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWALMode(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "kairos.db"))
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer db.Close()
+
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	old := busyTimeout
+	busyTimeout = 100 * time.Millisecond
+	defer func() { busyTimeout = old }()
+
+	path := filepath.Join(t.TempDir(), "kairos.db")
+	first, err := InitDB(path)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer first.Close()
+	second, err := InitDB(path)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	defer second.Close()
+
+	tx, err := first.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO goals (name) VALUES ('first')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Readers aren't blocked by the open write transaction
+	var count int
+	if err := second.QueryRow("SELECT COUNT(*) FROM goals").Scan(&count); err != nil {
+		t.Fatalf("read during write: %v", err)
+	}
+
+	// A second writer gives up with ErrBusy once the timeout passes
+	_, err = second.Begin()
+	if !errors.Is(CheckBusy(err), ErrBusy) {
+		t.Errorf("second writer error = %v, want ErrBusy", err)
+	}
+
+	// ...and gets through once the first one is done
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx2, err := second.Begin()
+	if err != nil {
+		t.Fatalf("second writer after commit: %v", err)
+	}
+	tx2.Rollback()
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Milestones whose subtask generation failed are saved anyway and flagged,
+-- so `kairos plan resume` can fill them in later.
+ALTER TABLE tasks ADD COLUMN needs_planning INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN needs_planning;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Keep what a plan was generated from, so replanning, exports and audits
+-- can see why it looks the way it does.
+ALTER TABLE goals ADD COLUMN context TEXT;
+ALTER TABLE goals ADD COLUMN planner TEXT;
+ALTER TABLE goals ADD COLUMN model TEXT;
+ALTER TABLE goals ADD COLUMN prompt_version INTEGER;
+ALTER TABLE goals ADD COLUMN raw_response TEXT;
+
+-- +goose Down
+ALTER TABLE goals DROP COLUMN raw_response;
+ALTER TABLE goals DROP COLUMN prompt_version;
+ALTER TABLE goals DROP COLUMN model;
+ALTER TABLE goals DROP COLUMN planner;
+ALTER TABLE goals DROP COLUMN context;
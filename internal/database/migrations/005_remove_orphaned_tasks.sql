@@ -0,0 +1,20 @@
+-- +goose Up
+-- Foreign keys were never enforced before, so deleting a goal left its tasks
+-- behind. Remove tasks whose goal or parent is gone, along with everything
+-- below them, and forget a current goal that no longer exists.
+WITH RECURSIVE orphans(id) AS (
+    SELECT id FROM tasks
+    WHERE goal_id IS NULL
+       OR goal_id NOT IN (SELECT id FROM goals)
+       OR (parent_task_id IS NOT NULL AND parent_task_id NOT IN (SELECT id FROM tasks))
+    UNION
+    SELECT t.id FROM tasks t JOIN orphans o ON t.parent_task_id = o.id
+)
+DELETE FROM tasks WHERE id IN (SELECT id FROM orphans);
+
+DELETE FROM app_state
+WHERE key = 'current_goal_id'
+  AND value NOT IN (SELECT CAST(id AS TEXT) FROM goals);
+
+-- +goose Down
+-- Deleted orphans can't be restored, and nothing references them.
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Append-only journal of every change to goals, tasks and the current goal.
+-- A CHANGE row carries before/after snapshots of the rows it touched; UNDO
+-- and REDO rows point at the CHANGE they reverted or reapplied.
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    kind TEXT NOT NULL,
+    action TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    changes TEXT NOT NULL DEFAULT '[]',
+    target_id INTEGER REFERENCES events(id)
+);
+
+CREATE INDEX events_target_id ON events(target_id);
+
+-- +goose Down
+DROP TABLE events;
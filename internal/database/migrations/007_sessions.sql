@@ -0,0 +1,15 @@
+-- +goose Up
+-- Time actually spent on a task in focus mode. ended_at is NULL while the
+-- session is running; there's at most one of those.
+CREATE TABLE sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME,
+    FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX sessions_task_id ON sessions(task_id);
+
+-- +goose Down
+DROP TABLE sessions;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Work intervals of the focus mode timer that ran to the end, credited to
+-- the task being worked on when they finished.
+CREATE TABLE pomodoros (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME NOT NULL,
+    FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX pomodoros_task_id ON pomodoros(task_id);
+
+-- +goose Down
+DROP TABLE pomodoros;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Why a subtask was SKIPPED, if the user gave a reason.
+ALTER TABLE tasks ADD COLUMN skip_reason TEXT;
+-- Subtasks deferred to the end of their milestone sort after the others, in
+-- the order they were deferred.
+ALTER TABLE tasks ADD COLUMN deferred INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN deferred;
+ALTER TABLE tasks DROP COLUMN skip_reason;
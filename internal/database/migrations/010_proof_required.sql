@@ -0,0 +1,6 @@
+-- +goose Up
+-- Goals whose tasks can only be checked off with proof of work attached.
+ALTER TABLE goals ADD COLUMN proof_required INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE goals DROP COLUMN proof_required;
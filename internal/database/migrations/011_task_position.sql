@@ -0,0 +1,17 @@
+-- +goose Up
+-- Explicit order of tasks among their siblings, so they can be reordered by
+-- hand. It takes over from the deferred counter, keeping the current order.
+ALTER TABLE tasks ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+UPDATE tasks SET position = (
+    SELECT COUNT(*) FROM tasks s
+    WHERE s.goal_id IS tasks.goal_id AND s.parent_task_id IS tasks.parent_task_id
+    AND (s.deferred < tasks.deferred OR (s.deferred = tasks.deferred AND s.id < tasks.id))
+);
+ALTER TABLE tasks DROP COLUMN deferred;
+CREATE INDEX tasks_parent_position ON tasks(parent_task_id, position);
+
+-- +goose Down
+DROP INDEX tasks_parent_position;
+ALTER TABLE tasks ADD COLUMN deferred INTEGER NOT NULL DEFAULT 0;
+UPDATE tasks SET deferred = position;
+ALTER TABLE tasks DROP COLUMN position;
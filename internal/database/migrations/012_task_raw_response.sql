@@ -0,0 +1,7 @@
+-- +goose Up
+-- Keep the planner reply each milestone's subtasks were parsed from, next to
+-- the goal's own raw_response.
+ALTER TABLE tasks ADD COLUMN raw_response TEXT;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN raw_response;
@@ -0,0 +1,108 @@
+// Package git reads the repositories kairos is used from through the git
+// command line.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DoneTrailer is the commit message trailer naming tasks a commit finishes,
+// e.g. "Kairos-Done: 42".
+const DoneTrailer = "Kairos-Done"
+
+// Commit is a commit's hash and subject line, with the tasks it finishes.
+type Commit struct {
+	SHA     string
+	Subject string
+	Done    []int64 // Task IDs from DoneTrailer trailers
+}
+
+// String formats the commit as a proof of work entry.
+func (c Commit) String() string {
+	return fmt.Sprintf("commit %s %s", c.SHA, c.Subject)
+}
+
+// Short is the abbreviated hash.
+func (c Commit) Short() string {
+	return c.SHA[:min(len(c.SHA), 7)]
+}
+
+// run runs git in dir and returns its trimmed output.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HeadCommit returns the commit checked out in the repository containing dir.
+func HeadCommit(dir string) (Commit, error) {
+	out, err := run(dir, "log", "-1", "--format=%H%x00%s%x00%(trailers:key="+DoneTrailer+",valueonly,separator=%x2C)")
+	if err != nil {
+		return Commit{}, err
+	}
+	fields := strings.SplitN(out, "\x00", 3)
+	for len(fields) < 3 {
+		fields = append(fields, "")
+	}
+
+	c := Commit{SHA: fields[0], Subject: fields[1]}
+	// Values can list several tasks: "Kairos-Done: 12, #13"
+	for _, ref := range strings.FieldsFunc(fields[2], func(r rune) bool { return r == ',' || r == ' ' }) {
+		id, err := strconv.ParseInt(strings.TrimPrefix(ref, "#"), 10, 64)
+		if err != nil {
+			return Commit{}, fmt.Errorf("%s trailer: %q is not a task ID", DoneTrailer, ref)
+		}
+		c.Done = append(c.Done, id)
+	}
+	return c, nil
+}
+
+// hookMarker identifies hooks written by InstallHook.
+const hookMarker = "# Installed by kairos"
+
+// ErrForeignHook is returned by InstallHook when the repository already has
+// a hook of that name that kairos didn't write.
+var ErrForeignHook = errors.New("a hook that kairos didn't install is in the way")
+
+// InstallHook writes a shell hook running command into the repository
+// containing dir, honouring core.hooksPath. A hook kairos installed before
+// is replaced; any other one is left alone. It returns the hook's path.
+func InstallHook(dir, name, command string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	hooks, err := run(dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	// Relative paths are relative to dir
+	if !filepath.IsAbs(hooks) {
+		hooks = filepath.Join(dir, hooks)
+	}
+	path := filepath.Join(hooks, name)
+
+	if old, err := os.ReadFile(path); err == nil && !bytes.Contains(old, []byte(hookMarker)) {
+		return path, fmt.Errorf("%s: %w", path, ErrForeignHook)
+	}
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		return path, err
+	}
+	script := fmt.Sprintf("#!/bin/sh\n%s; see 'kairos hook install'.\n%s\n", hookMarker, command)
+	return path, os.WriteFile(path, []byte(script), 0755)
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newRepo creates a throwaway repository with an identity to commit as.
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	git(t, dir, "config", "user.name", "Test")
+	git(t, dir, "config", "user.email", "test@example.com")
+	git(t, dir, "config", "commit.gpgsign", "false")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := run(dir, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commit(t *testing.T, dir, message string) {
+	t.Helper()
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", message)
+}
+
+func TestHeadCommit(t *testing.T) {
+	dir := newRepo(t)
+	commit(t, dir, "Add the parser\n\nCovers the grammar.\n\nKairos-Done: 12, #13\nKairos-Done: 14\n")
+
+	c, err := HeadCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.SHA) != 40 || c.Subject != "Add the parser" {
+		t.Errorf("commit = %q %q", c.SHA, c.Subject)
+	}
+	if want := []int64{12, 13, 14}; !reflect.DeepEqual(c.Done, want) {
+		t.Errorf("Done = %v, want %v", c.Done, want)
+	}
+
+	commit(t, dir, "Tidy up")
+	if c, err := HeadCommit(dir); err != nil || c.Done != nil {
+		t.Errorf("commit without trailers = %+v, %v", c, err)
+	}
+
+	commit(t, dir, "Tidy up\n\nKairos-Done: soon\n")
+	if _, err := HeadCommit(dir); err == nil {
+		t.Error("a trailer that isn't a task ID should fail")
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	dir := newRepo(t)
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hooks run from the top of the work tree
+	path, err := InstallHook(sub, "post-commit", "git log -1 --format=%s > hook-ran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks", "post-commit"); path != want {
+		t.Errorf("hook at %s, want %s", path, want)
+	}
+	commit(t, dir, "First")
+	if out, err := os.ReadFile(filepath.Join(dir, "hook-ran")); err != nil || string(out) != "First\n" {
+		t.Errorf("hook output = %q, %v", out, err)
+	}
+
+	// Installing again replaces the hook
+	if _, err := InstallHook(dir, "post-commit", "true"); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstallHook(dir, "post-commit", "true"); !errors.Is(err, ErrForeignHook) {
+		t.Errorf("install over a foreign hook = %v, want ErrForeignHook", err)
+	}
+}
@@ -8,8 +8,17 @@ import (
 type Goal struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
-	Status    string    `json:"status"` // ACTIVE, ARCHIVED, COMPLETED
+	Status    string    `json:"status"` // ACTIVE, IDLE, ARCHIVED, COMPLETED
 	CreatedAt time.Time `json:"created_at"`
+
+	// Planning metadata, empty for goals created before it was recorded
+	Context       sql.NullString `json:"context"`
+	Planner       sql.NullString `json:"planner"`
+	Model         sql.NullString `json:"model"`
+	PromptVersion sql.NullInt64  `json:"prompt_version"`
+	RawResponse   sql.NullString `json:"raw_response"`
+
+	ProofRequired bool `json:"proof_required"` // Tasks can't be checked off without proof of work
 }
 
 type Task struct {
@@ -19,5 +28,25 @@ type Task struct {
 	Description           string         `json:"description"`
 	Status                string         `json:"status"` // PENDING, IN_PROGRESS, DONE, SKIPPED
 	EstimatedDurationMins sql.NullInt64  `json:"estimated_duration_mins"`
-	ProofOfWork           sql.NullString `json:"proof_of_work"`
+	ProofOfWork           sql.NullString `json:"proof_of_work"`  // One entry per line: a note, URL, file path or "commit <sha> <subject>"
+	SkipReason            sql.NullString `json:"skip_reason"`    // Optional, for SKIPPED tasks
+	NeedsPlanning         bool           `json:"needs_planning"` // Milestone whose subtasks still have to be generated
+	RawResponse           sql.NullString `json:"raw_response"`   // Planner reply the task's subtasks were parsed from
+}
+
+type Session struct {
+	ID        int64        `json:"id"`
+	TaskID    int64        `json:"task_id"`
+	StartedAt time.Time    `json:"started_at"`
+	EndedAt   sql.NullTime `json:"ended_at"` // Null while the session is running
+}
+
+type Event struct {
+	ID        int64         `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Kind      string        `json:"kind"`   // CHANGE, UNDO, REDO
+	Action    string        `json:"action"` // e.g. toggle_subtask, delete_goal
+	Summary   string        `json:"summary"`
+	TargetID  sql.NullInt64 `json:"target_id"` // The CHANGE an UNDO or REDO applies to
+	Undone    bool          `json:"undone"`    // CHANGE currently reverted
 }
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CheckIntegrity looks for problems in the database: corruption, rows whose
+// foreign keys point nowhere, and a current goal that no longer exists. It
+// returns one human-readable line per problem.
+func (s *Store) CheckIntegrity() ([]string, error) {
+	var problems []string
+
+	var foreignKeys bool
+	if err := s.db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return nil, err
+	}
+	if !foreignKeys {
+		problems = append(problems, "foreign key enforcement is off; deleting a goal won't remove its tasks")
+	}
+
+	rows, err := s.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, "integrity check: "+msg)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkID int
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		problems = append(problems, fmt.Sprintf("%s row %d references a missing %s row", table, rowID.Int64, parent))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var value string
+	err = s.db.QueryRow(`
+		SELECT value FROM app_state
+		WHERE key = 'current_goal_id' AND value NOT IN (SELECT CAST(id AS TEXT) FROM goals)`).Scan(&value)
+	if err == nil {
+		problems = append(problems, fmt.Sprintf("current goal %s doesn't exist", value))
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,297 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+// nextPosition is the position after the last of a parent's children, taking
+// the goal ID and the parent ID (NULL for milestones) as arguments.
+const nextPosition = `(SELECT COALESCE(MAX(position) + 1, 0) FROM tasks WHERE goal_id = ? AND parent_task_id IS ?)`
+
+// siblingIDs lists the tasks sharing a task's parent, the task included, in
+// plan order.
+func siblingIDs(q querier, t *models.Task) ([]int64, error) {
+	rows, err := q.Query("SELECT id FROM tasks WHERE goal_id = ? AND parent_task_id IS ? ORDER BY position, id", t.GoalID, t.ParentTaskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// reorder gives tasks consecutive positions in the order given.
+func reorder(j *journal, ids []int64) error {
+	for i, id := range ids {
+		var position int
+		if err := j.tx.QueryRow("SELECT position FROM tasks WHERE id = ?", id).Scan(&position); err != nil {
+			return err
+		}
+		if position == i {
+			continue
+		}
+		if err := j.track("tasks", id); err != nil {
+			return err
+		}
+		if _, err := j.tx.Exec("UPDATE tasks SET position = ? WHERE id = ?", i, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveTask puts a task at index to among its siblings, clamped to the ends.
+func moveTask(j *journal, t *models.Task, to int) error {
+	ids, err := siblingIDs(j.tx, t)
+	if err != nil {
+		return err
+	}
+	ids = slices.DeleteFunc(ids, func(id int64) bool { return id == t.ID })
+	to = min(max(to, 0), len(ids))
+	return reorder(j, slices.Insert(ids, to, t.ID))
+}
+
+func nullMins(mins int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(mins), Valid: mins > 0}
+}
+
+// AddMilestone appends a milestone to a goal. It has no subtasks yet, so it's
+// flagged as needing planning until some are added.
+func (s *Store) AddMilestone(goalID int64, description string, mins int) (int64, error) {
+	var id int64
+	err := s.withJournal("add_task", func(j *journal) error {
+		j.describe("Added milestone '%s' to '%s'", description, goalName(j.tx, goalID))
+
+		res, err := j.tx.Exec("INSERT INTO tasks (goal_id, description, status, estimated_duration_mins, needs_planning, position) VALUES (?, ?, 'PENDING', ?, 1, "+nextPosition+")",
+			goalID, description, nullMins(mins), goalID, nil)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		j.trackNew("tasks", id)
+		return reopenGoal(j, goalID)
+	})
+	return id, err
+}
+
+// AddSubtask appends a subtask to a task, which from then on is finished
+// through its subtasks.
+func (s *Store) AddSubtask(parentID int64, description string, mins int) (int64, error) {
+	var id int64
+	err := s.withJournal("add_task", func(j *journal) error {
+		parent, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", parentID))
+		if err != nil {
+			return err
+		}
+		j.describe("Added '%s' to '%s'", description, parent.Description)
+
+		ids, err := addSubtasks(j, parent, []ai.PlanItem{{Title: description, EstimatedDurationMins: mins}})
+		if err != nil {
+			return err
+		}
+		id = ids[0]
+		return nil
+	})
+	return id, err
+}
+
+// SplitTask breaks an unfinished subtask down into the subtasks of a planner
+// response, keeping the raw reply with the task. Only tasks worked on
+// directly can be split: milestones are planned instead, and a task that
+// already has subtasks is broken down through those.
+func (s *Store) SplitTask(id int64, resp *ai.Response) ([]int64, error) {
+	var ids []int64
+	err := s.withJournal("split_task", func(j *journal) error {
+		t, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		if t.Status == "DONE" || t.Status == "SKIPPED" {
+			return fmt.Errorf("'%s' is already %s", t.Description, strings.ToLower(t.Status))
+		}
+		if !t.ParentTaskID.Valid {
+			return fmt.Errorf("'%s' is a milestone; add subtasks to it or replan instead", t.Description)
+		}
+		var split bool
+		if err := j.tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE parent_task_id = ?)", id).Scan(&split); err != nil {
+			return err
+		}
+		if split {
+			return fmt.Errorf("'%s' already has subtasks; split one of those instead", t.Description)
+		}
+		if len(resp.Items) == 0 {
+			return errors.New("nothing to split into")
+		}
+		j.describe("Split '%s' into %d subtasks", t.Description, len(resp.Items))
+
+		if ids, err = addSubtasks(j, t, resp.Items); err != nil {
+			return err
+		}
+		_, err = j.tx.Exec("UPDATE tasks SET raw_response = NULLIF(?, '') WHERE id = ?", resp.Raw, id)
+		return err
+	})
+	return ids, err
+}
+
+// ancestorsQuery selects a task and every task above it.
+const ancestorsQuery = `
+	WITH RECURSIVE up(id) AS (
+		SELECT ?
+		UNION ALL
+		SELECT t.parent_task_id FROM tasks t JOIN up ON t.id = up.id WHERE t.parent_task_id IS NOT NULL
+	)
+	SELECT id FROM up`
+
+// addSubtasks appends subtasks to a task. The task stops being worked on
+// directly and no longer needs planning, and it and the tasks above it are
+// reopened if they were done.
+func addSubtasks(j *journal, parent *models.Task, subtasks []ai.PlanItem) ([]int64, error) {
+	if err := j.trackQuery("tasks", ancestorsQuery, parent.ID); err != nil {
+		return nil, err
+	}
+	ids, err := insertSubtasks(j, parent.GoalID, parent.ID, subtasks)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := j.tx.Exec("UPDATE tasks SET needs_planning = 0 WHERE id = ?", parent.ID); err != nil {
+		return nil, err
+	}
+	if _, err := j.tx.Exec("UPDATE tasks SET status = 'IN_PROGRESS' WHERE status = 'DONE' AND id IN ("+ancestorsQuery+")", parent.ID); err != nil {
+		return nil, err
+	}
+	if err := reopenGoal(j, parent.GoalID); err != nil {
+		return nil, err
+	}
+	return ids, stopSessions(j.tx, time.Now(), "task_id = ?", parent.ID)
+}
+
+// EditTask changes a task's description and estimate. An empty description
+// or an invalid estimate leaves that part as it is; an estimate of 0 clears
+// it.
+func (s *Store) EditTask(id int64, description string, estimate sql.NullInt64) error {
+	return s.withJournal("edit_task", func(j *journal) error {
+		t, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		if err := j.track("tasks", id); err != nil {
+			return err
+		}
+
+		j.describe("Edited '%s'", t.Description)
+		if description != "" && description != t.Description {
+			j.describe("Renamed '%s' to '%s'", t.Description, description)
+			if _, err := j.tx.Exec("UPDATE tasks SET description = ? WHERE id = ?", description, id); err != nil {
+				return err
+			}
+		}
+		if estimate.Valid {
+			if _, err := j.tx.Exec("UPDATE tasks SET estimated_duration_mins = ? WHERE id = ?", nullMins(int(estimate.Int64)), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTask removes a task along with everything under it. When it was a
+// subtask, its milestone is kept in step with the ones left; a parent left
+// without subtasks is PENDING again, and a milestone needs planning.
+func (s *Store) DeleteTask(id int64) error {
+	return s.withJournal("delete_task", func(j *journal) error {
+		t, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		j.describe("Deleted '%s'", t.Description)
+
+		// Time first and tasks deepest first, so undo restores parents before
+		// what hangs off them
+		const below = `
+			WITH RECURSIVE below(id, depth) AS (
+				SELECT id, 0 FROM tasks WHERE id = ?
+				UNION ALL
+				SELECT t.id, below.depth + 1 FROM tasks t JOIN below ON t.parent_task_id = below.id
+			)
+			SELECT id FROM below`
+		if err := j.trackTaskTime(below, id); err != nil {
+			return err
+		}
+		if err := j.trackQuery("tasks", below+" ORDER BY depth DESC, id", id); err != nil {
+			return err
+		}
+		if _, err := j.tx.Exec("DELETE FROM tasks WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		ids, err := siblingIDs(j.tx, t)
+		if err != nil {
+			return err
+		}
+		if err := reorder(j, ids); err != nil {
+			return err
+		}
+		if !t.ParentTaskID.Valid {
+			return nil
+		}
+		if len(ids) > 0 {
+			_, err = syncParents(j, t.ParentTaskID.Int64)
+			return err
+		}
+		return emptyParent(j, t.ParentTaskID.Int64)
+	})
+}
+
+// emptyParent resets a task whose last subtask was deleted: it's worked on
+// directly again, or planned anew if it's a milestone.
+func emptyParent(j *journal, id int64) error {
+	if err := j.track("tasks", id); err != nil {
+		return err
+	}
+	_, err := j.tx.Exec("UPDATE tasks SET status = 'PENDING', needs_planning = (parent_task_id IS NULL) WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	var goalID int64
+	var parent sql.NullInt64
+	if err := j.tx.QueryRow("SELECT goal_id, parent_task_id FROM tasks WHERE id = ?", id).Scan(&goalID, &parent); err != nil {
+		return err
+	}
+	if parent.Valid {
+		if _, err := syncParents(j, parent.Int64); err != nil {
+			return err
+		}
+	}
+	return reopenGoal(j, goalID)
+}
+
+// MoveTask puts a task at index to (0-based) among its siblings.
+func (s *Store) MoveTask(id int64, to int) error {
+	return s.withJournal("move_task", func(j *journal) error {
+		t, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		j.describe("Moved '%s' to position %d", t.Description, to+1)
+		return moveTask(j, t, to)
+	})
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+)
+
+func TestAddTask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	for _, sub := range subtasks {
+		if _, err := s.ToggleSubtask(sub.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A subtask reopens its milestone; a milestone goes last and needs planning
+	if _, err := s.AddSubtask(milestone.ID, "Exercises", 15); err != nil {
+		t.Fatalf("AddSubtask: %v", err)
+	}
+	mid, err := s.AddMilestone(id, "Projects", 0)
+	if err != nil {
+		t.Fatalf("AddMilestone: %v", err)
+	}
+	want := []string{"Basics:IN_PROGRESS", "Read:DONE", "Practice:DONE", "Exercises:PENDING", "Advanced:PENDING", "Projects:PENDING"}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+	if m, _ := s.Task(mid); !m.NeedsPlanning || m.EstimatedDurationMins.Valid {
+		t.Errorf("new milestone = %+v, want needing planning without an estimate", m)
+	}
+
+	if _, err := s.Undo(2); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got, _ := s.Task(milestone.ID); got.Status != "DONE" {
+		t.Errorf("milestone status after undo = %s, want DONE", got.Status)
+	}
+}
+
+func TestEditTask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if err := s.EditTask(subtasks[0].ID, "Read the tour", sql.NullInt64{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EditTask(subtasks[1].ID, "", sql.NullInt64{Int64: 0, Valid: true}); err != nil {
+		t.Fatal(err)
+	}
+	read, _ := s.Task(subtasks[0].ID)
+	practice, _ := s.Task(subtasks[1].ID)
+	if read.Description != "Read the tour" || read.EstimatedDurationMins.Int64 != 20 {
+		t.Errorf("renamed task = %+v", read)
+	}
+	if practice.Description != "Practice" || practice.EstimatedDurationMins.Valid {
+		t.Errorf("task with a cleared estimate = %+v", practice)
+	}
+
+	events, _ := s.Events(1)
+	if events[0].Summary != "Edited 'Practice'" {
+		t.Errorf("summary = %q", events[0].Summary)
+	}
+}
+
+func TestDeleteTask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	before := dump(t, s, id)
+
+	// Deleting the last open subtask completes the milestone
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTask(subtasks[1].ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if got, want := dump(t, s, id), []string{"Basics:DONE", "Read:DONE", "Advanced:PENDING"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after deleting a subtask = %v, want %v", got, want)
+	}
+
+	// A milestone left without subtasks is planned anew
+	if err := s.DeleteTask(subtasks[0].ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if got, want := dump(t, s, id), []string{"Basics:PENDING", "Advanced:PENDING"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after deleting the last subtask = %v, want %v", got, want)
+	}
+	if m, _ := s.Task(milestone.ID); !m.NeedsPlanning {
+		t.Error("emptied milestone doesn't need planning")
+	}
+
+	// A milestone goes with its subtasks, and undo brings them all back
+	if err := s.DeleteTask(milestone.ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if got, want := dump(t, s, id), []string{"Advanced:PENDING"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after deleting a milestone = %v, want %v", got, want)
+	}
+	if _, err := s.Undo(4); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, before) {
+		t.Errorf("after undo = %v, want %v", got, before)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if err := s.MoveTask(subtasks[1].ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Milestones move with their subtasks, and positions are clamped
+	if err := s.MoveTask(milestone.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Advanced:PENDING", "Basics:PENDING", "Practice:PENDING", "Read:PENDING"}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+	if next, _ := s.NextMilestone(id); next.Description != "Advanced" {
+		t.Errorf("next milestone = %q, want Advanced", next.Description)
+	}
+
+	if _, err := s.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := s.NextMilestone(id); next.Description != "Basics" {
+		t.Errorf("next milestone after undo = %q, want Basics", next.Description)
+	}
+}
+
+func TestSplitTask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	read := subtasks[0].ID
+	if err := s.StartSession(read); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := s.SplitTask(read, &ai.Response{Items: []ai.PlanItem{item("Chapter 1", 10), item("Chapter 2", 10)}, Raw: "split reply"})
+	if err != nil {
+		t.Fatalf("SplitTask: %v", err)
+	}
+	if task, _ := s.Task(read); task.RawResponse.String != "split reply" {
+		t.Errorf("raw response = %q, want %q", task.RawResponse.String, "split reply")
+	}
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("the split task is still being worked on: %v", err)
+	}
+	if _, err := s.ToggleSubtask(read); err == nil {
+		t.Error("checked off a task that was split")
+	}
+	if mins, _ := s.RemainingMins(milestone.ID); mins != 60 {
+		t.Errorf("milestone left = %d minutes, want 60", mins)
+	}
+
+	// The milestone is done once the tasks at every level are
+	if _, err := s.ToggleSubtask(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SkipSubtask(ids[1], ""); err != nil {
+		t.Fatal(err)
+	}
+	done, err := s.ToggleSubtask(subtasks[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Basics:DONE", "Read:DONE", "Chapter 1:DONE", "Chapter 2:SKIPPED", "Practice:DONE", "Advanced:PENDING"}
+	if got := dump(t, s, id); !done || !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v (milestone done %v), want %v", got, done, want)
+	}
+
+	if _, err := s.SplitTask(read, &ai.Response{Items: []ai.PlanItem{item("Again", 5)}}); err == nil {
+		t.Error("split a finished task")
+	}
+}
+
+func TestSplitTaskRefused(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	if _, err := s.SplitTask(subtasks[0].ID, &ai.Response{Items: []ai.PlanItem{item("Chapter 1", 10)}}); err != nil {
+		t.Fatal(err)
+	}
+	before := dump(t, s, id)
+
+	tasks, _ := s.Tasks(id)
+	advanced := tasks[len(tasks)-1]
+	for name, taskID := range map[string]int64{
+		"a milestone with subtasks":    milestone.ID,
+		"a milestone without subtasks": advanced.ID,
+		"a task that was split":        subtasks[0].ID,
+	} {
+		if _, err := s.SplitTask(taskID, &ai.Response{Items: []ai.PlanItem{item("Extra", 5)}}); err == nil {
+			t.Errorf("split %s", name)
+		}
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, before) {
+		t.Errorf("refused splits changed the plan: %v, want %v", got, before)
+	}
+}
+
+func TestReplanKeepsSplitWork(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	ids, err := s.SplitTask(subtasks[0].ID, &ai.Response{Items: []ai.PlanItem{item("Chapter 1", 10), item("Chapter 2", 10)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ToggleSubtask(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	goal, _ := s.Goal(id)
+	if err := s.ReplacePendingPlan(*goal, []ai.Milestone{{PlanItem: item("Projects", 120)}}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Basics:SKIPPED", "Read:SKIPPED", "Chapter 1:DONE", "Projects:PENDING"}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+}
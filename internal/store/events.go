@@ -0,0 +1,119 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+// lastKind is the kind of the latest UNDO or REDO of event e, NULL if it was
+// never undone.
+const lastKind = `(SELECT r.kind FROM events r WHERE r.target_id = e.id ORDER BY r.id DESC LIMIT 1)`
+
+const eventColumns = `e.id, e.created_at, e.kind, e.action, e.summary, e.target_id, COALESCE(` + lastKind + ` = 'UNDO', 0)`
+
+func scanEvent(row interface{ Scan(...any) error }, extra ...any) (*models.Event, error) {
+	var e models.Event
+	err := row.Scan(append([]any{&e.ID, &e.CreatedAt, &e.Kind, &e.Action, &e.Summary, &e.TargetID, &e.Undone}, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Events returns the newest events of the journal, newest first.
+func (s *Store) Events(limit int) ([]models.Event, error) {
+	rows, err := s.db.Query("SELECT "+eventColumns+" FROM events e ORDER BY e.id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// Undo reverts the last n changes that are still in effect, newest first,
+// in one transaction. It stops early when there's nothing left to undo and
+// returns the changes it reverted.
+func (s *Store) Undo(n int) ([]models.Event, error) {
+	return s.replay(n, true, `
+		SELECT `+eventColumns+`, e.changes
+		FROM events e
+		WHERE e.kind = 'CHANGE' AND COALESCE(`+lastKind+`, 'REDO') = 'REDO'
+		ORDER BY e.id DESC LIMIT 1`)
+}
+
+// Redo reapplies changes reverted by Undo, most recently undone first. Once
+// a new change is recorded, earlier undos can no longer be redone.
+func (s *Store) Redo(n int) ([]models.Event, error) {
+	return s.replay(n, false, `
+		SELECT `+eventColumns+`, e.changes
+		FROM events e
+		JOIN events u ON u.id = (SELECT MAX(r.id) FROM events r WHERE r.target_id = e.id)
+		WHERE e.kind = 'CHANGE' AND u.kind = 'UNDO'
+		AND u.id > (SELECT COALESCE(MAX(id), 0) FROM events WHERE kind = 'CHANGE')
+		ORDER BY u.id DESC LIMIT 1`)
+}
+
+// replay undoes or redoes up to n events, picking each with next.
+func (s *Store) replay(n int, undo bool, next string) ([]models.Event, error) {
+	verb, kind := "redo", "REDO"
+	if undo {
+		verb, kind = "undo", "UNDO"
+	}
+
+	var done []models.Event
+	err := s.withTx(func(tx *sql.Tx) error {
+		for range n {
+			var data string
+			e, err := scanEvent(tx.QueryRow(next), &data)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			var changes []rowChange
+			if err := decodeJSON([]byte(data), &changes); err != nil {
+				return fmt.Errorf("event %d: %w", e.ID, err)
+			}
+			// Rows go back in the reverse of the order they changed in
+			if undo {
+				for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+					changes[i], changes[j] = changes[j], changes[i]
+				}
+			}
+			for _, c := range changes {
+				if err := c.apply(tx, undo); err != nil {
+					return fmt.Errorf("can't %s %q: %w", verb, e.Summary, err)
+				}
+			}
+
+			_, err = tx.Exec("INSERT INTO events (created_at, kind, action, summary, target_id) VALUES (?, ?, ?, ?, ?)",
+				time.Now(), kind, e.Action, e.Summary, e.ID)
+			if err != nil {
+				return err
+			}
+			done = append(done, *e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, nil
+}
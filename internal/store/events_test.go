@@ -0,0 +1,320 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+)
+
+// dump lists a goal's tasks as description:status, to compare whole plans.
+func dump(t *testing.T, s *Store, goalID int64) []string {
+	t.Helper()
+	tasks, err := s.Tasks(goalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, task := range tasks {
+		out = append(out, task.Description+":"+task.Status)
+	}
+	return out
+}
+
+func TestUndoRedoToggle(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	before := dump(t, s, id)
+
+	for _, sub := range subtasks {
+		if _, err := s.ToggleSubtask(sub.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	after := dump(t, s, id)
+
+	undone, err := s.Undo(2)
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if len(undone) != 2 || undone[0].Summary != "Checked 'Practice', completing its milestone" || undone[1].Summary != "Checked 'Read'" {
+		t.Errorf("undone = %+v", undone)
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, before) {
+		t.Errorf("after undo = %v, want %v", got, before)
+	}
+
+	redone, err := s.Redo(5)
+	if err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if len(redone) != 2 || redone[0].Summary != "Checked 'Read'" {
+		t.Errorf("redone = %+v", redone)
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, after) {
+		t.Errorf("after redo = %v, want %v", got, after)
+	}
+}
+
+func TestUndoDeleteGoal(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	before := dump(t, s, id)
+	orig, err := s.Goal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteGoal(id); err != nil {
+		t.Fatal(err)
+	}
+	var pointers int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM app_state WHERE key = 'current_goal_id'").Scan(&pointers); err != nil || pointers != 0 {
+		t.Errorf("current goal still set after deleting it: %d, %v", pointers, err)
+	}
+	// The delete is the change undo reverses, nothing recorded after it
+	if events, _ := s.Events(1); events[0].Action != "delete_goal" {
+		t.Errorf("last event = %+v, want the delete", events[0])
+	}
+	if _, err := s.Undo(1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+
+	g, err := s.Goal(id)
+	if err != nil {
+		t.Fatalf("goal not restored: %v", err)
+	}
+	if g.Name != orig.Name || g.Status != orig.Status || !g.CreatedAt.Equal(orig.CreatedAt) {
+		t.Errorf("restored goal = %+v", g)
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, before) {
+		t.Errorf("restored tasks = %v, want %v", got, before)
+	}
+	if active, err := s.ActiveGoal(); err != nil || active.ID != id {
+		t.Errorf("ActiveGoal = %v, %v; want the restored goal", active, err)
+	}
+}
+
+func TestUndoCreateGoal(t *testing.T) {
+	s := newTestStore(t)
+	first := createGoal(t, s, "Learn Go")
+	second := createGoal(t, s, "Ship v2")
+
+	if _, err := s.Undo(1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := s.Goal(second); !errors.Is(err, ErrNotFound) {
+		t.Errorf("undone goal still exists: %v", err)
+	}
+	if tasks, _ := s.Tasks(second); len(tasks) != 0 {
+		t.Errorf("undone goal left %d tasks", len(tasks))
+	}
+	if active, err := s.ActiveGoal(); err != nil || active.ID != first {
+		t.Errorf("ActiveGoal = %v, %v; want the first goal back", active, err)
+	}
+}
+
+func TestUndoReplan(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	before := dump(t, s, id)
+
+	goal, _ := s.Goal(id)
+	err := s.ReplacePendingPlan(*goal, []ai.Milestone{
+		{PlanItem: item("Projects", 120), Subtasks: []ai.PlanItem{item("CLI tool", 120)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, before) {
+		t.Errorf("after undo = %v, want %v", got, before)
+	}
+}
+
+func TestRedoClearedByNewChange(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ToggleSubtask(subtasks[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	redone, err := s.Redo(1)
+	if err != nil || len(redone) != 0 {
+		t.Errorf("Redo after a new change = %v, %v; want nothing", redone, err)
+	}
+
+	// Undo skips the change that's already undone
+	undone, err := s.Undo(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(undone) != 2 || undone[0].Summary != "Checked 'Practice'" || undone[1].Action != "create_goal" {
+		t.Errorf("undone = %+v", undone)
+	}
+}
+
+func TestUndoConflict(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	// A change the journal doesn't know about
+	if _, err := s.db.Exec("UPDATE tasks SET description = 'Read the spec' WHERE id = ?", subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	before := dump(t, s, id)
+
+	if _, err := s.Undo(1); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Undo error = %v, want ErrConflict", err)
+	}
+	if got := dump(t, s, id); !reflect.DeepEqual(got, before) {
+		t.Errorf("failed undo changed data: %v, want %v", got, before)
+	}
+	events, err := s.Events(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[0].Kind != "CHANGE" || events[0].Undone {
+		t.Errorf("failed undo was recorded: %+v", events[0])
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	if err := s.ArchiveGoal(id); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing changes, so nothing is recorded
+	if err := s.ClearActiveGoal(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := s.Events(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Kind+" "+e.Summary)
+	}
+	want := []string{"UNDO Archived goal 'Learn Go'", "CHANGE Archived goal 'Learn Go'", "CHANGE Created goal 'Learn Go'"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if !events[1].Undone || events[2].Undone {
+		t.Errorf("undone flags = %v, %v; want true, false", events[1].Undone, events[2].Undone)
+	}
+	if events[0].TargetID.Int64 != events[1].ID {
+		t.Errorf("undo targets %d, want %d", events[0].TargetID.Int64, events[1].ID)
+	}
+}
+
+func TestUndoDeleteKeepsTime(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	start := time.Now().Add(-time.Hour)
+	_, err := s.db.Exec("INSERT INTO sessions (task_id, started_at, ended_at) VALUES (?, ?, ?)", subtasks[0].ID, start, start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordPomodoro(subtasks[0].ID, start, start.Add(25*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, del := range []func() error{
+		func() error { return s.DeleteTask(milestone.ID) },
+		func() error { return s.DeleteGoal(id) },
+	} {
+		if err := del(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Undo(1); err != nil {
+			t.Fatalf("Undo: %v", err)
+		}
+		spent, err := s.TimeSpent(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spent[subtasks[0].ID] != 30*time.Minute {
+			t.Errorf("time spent after undoing the delete = %v, want 30m", spent[subtasks[0].ID])
+		}
+		if pomodoros, _ := s.Pomodoros(id); pomodoros[subtasks[0].ID] != 1 {
+			t.Errorf("pomodoros after undoing the delete = %v, want 1", pomodoros)
+		}
+	}
+
+	// Redo deletes it again
+	if _, err := s.Redo(1); err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if spent, _ := s.TimeSpent(id); len(spent) != 0 {
+		t.Errorf("time left after redoing the delete: %v", spent)
+	}
+}
+
+func TestUndoGoalCompletion(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	tasks, _ := s.Tasks(id)
+	advanced := tasks[len(tasks)-1]
+	if err := s.DeleteTask(advanced.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	for _, sub := range subtasks {
+		if _, err := s.ToggleSubtask(sub.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g, _ := s.Goal(id); g.Status != "COMPLETED" {
+		t.Fatalf("goal status = %s, want COMPLETED", g.Status)
+	}
+
+	// One undo reopens both the milestone and the goal
+	undone, err := s.Undo(1)
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if undone[0].Summary != "Checked 'Practice', completing its milestone and the goal" {
+		t.Errorf("undone = %q", undone[0].Summary)
+	}
+	if g, _ := s.Goal(id); g.Status != "ACTIVE" {
+		t.Errorf("goal status after undo = %s, want ACTIVE", g.Status)
+	}
+	if _, err := s.NextMilestone(id); err != nil {
+		t.Errorf("NextMilestone after undo: %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+const goalColumns = `id, name, status, created_at, context, planner, model, prompt_version, raw_response, proof_required`
+
+func scanGoal(row interface{ Scan(...any) error }) (*models.Goal, error) {
+	var g models.Goal
+	err := row.Scan(&g.ID, &g.Name, &g.Status, &g.CreatedAt, &g.Context, &g.Planner, &g.Model, &g.PromptVersion, &g.RawResponse, &g.ProofRequired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Goal returns the goal with the given ID.
+func (s *Store) Goal(id int64) (*models.Goal, error) {
+	return scanGoal(s.db.QueryRow("SELECT "+goalColumns+" FROM goals WHERE id = ?", id))
+}
+
+// Goals returns every goal, oldest first.
+func (s *Store) Goals() ([]models.Goal, error) {
+	rows, err := s.db.Query("SELECT " + goalColumns + " FROM goals ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []models.Goal
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *g)
+	}
+	return goals, rows.Err()
+}
+
+// FindGoal looks a goal up by ID or by case-insensitive name. An empty ref
+// means the active goal.
+func (s *Store) FindGoal(ref string) (*models.Goal, error) {
+	if ref == "" {
+		return s.ActiveGoal()
+	}
+
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		g, err := s.Goal(id)
+		if !errors.Is(err, ErrNotFound) {
+			return g, err
+		}
+	}
+
+	return scanGoal(s.db.QueryRow("SELECT "+goalColumns+" FROM goals WHERE name = ? COLLATE NOCASE ORDER BY id DESC LIMIT 1", ref))
+}
+
+// ActiveGoal returns the goal currently selected for focus mode, or
+// ErrNoActiveGoal.
+func (s *Store) ActiveGoal() (*models.Goal, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM app_state WHERE key = 'current_goal_id'").Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveGoal
+	} else if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, ErrNoActiveGoal
+	}
+	g, err := s.Goal(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrNoActiveGoal
+	}
+	return g, err
+}
+
+// SetActiveGoal selects a goal for focus mode and marks it ACTIVE; every
+// other active goal becomes IDLE.
+func (s *Store) SetActiveGoal(id int64) error {
+	return s.withJournal("switch_goal", func(j *journal) error {
+		j.describe("Switched to '%s'", goalName(j.tx, id))
+		if err := j.trackQuery("goals", "SELECT id FROM goals WHERE status = 'ACTIVE' OR id = ? ORDER BY id", id); err != nil {
+			return err
+		}
+		if err := setActiveGoal(j, id); err != nil {
+			return err
+		}
+		if _, err := j.tx.Exec("UPDATE goals SET status = 'IDLE' WHERE status = 'ACTIVE' AND id != ?", id); err != nil {
+			return err
+		}
+		_, err := j.tx.Exec("UPDATE goals SET status = 'ACTIVE' WHERE id = ?", id)
+		return err
+	})
+}
+
+func setActiveGoal(j *journal, id int64) error {
+	if err := j.track("app_state", "current_goal_id"); err != nil {
+		return err
+	}
+	_, err := j.tx.Exec("INSERT OR REPLACE INTO app_state (key, value) VALUES ('current_goal_id', ?)", id)
+	return err
+}
+
+// goalName is used to describe events; it falls back to the ID.
+func goalName(q querier, id int64) string {
+	var name string
+	if err := q.QueryRow("SELECT name FROM goals WHERE id = ?", id).Scan(&name); err != nil {
+		return fmt.Sprintf("#%d", id)
+	}
+	return name
+}
+
+// ClearActiveGoal forgets the selected goal.
+func (s *Store) ClearActiveGoal() error {
+	return s.withJournal("clear_goal", func(j *journal) error {
+		j.describe("Cleared the current goal")
+		if err := j.track("app_state", "current_goal_id"); err != nil {
+			return err
+		}
+		_, err := j.tx.Exec("DELETE FROM app_state WHERE key = 'current_goal_id'")
+		return err
+	})
+}
+
+// completeGoal marks the goal of a just-finished milestone COMPLETED once
+// none of its milestones are left to do. It's part of the change that
+// finished the milestone, so undoing that reopens the goal too.
+func completeGoal(j *journal, milestoneID int64) error {
+	var goalID int64
+	var open int
+	err := j.tx.QueryRow(`
+		SELECT goal_id, (
+			SELECT COUNT(*) FROM tasks m
+			WHERE m.goal_id = t.goal_id AND m.parent_task_id IS NULL AND m.status IN ('PENDING', 'IN_PROGRESS')
+		)
+		FROM tasks t WHERE id = ?`, milestoneID).Scan(&goalID, &open)
+	if err != nil || open > 0 {
+		return err
+	}
+
+	if err := j.track("goals", goalID); err != nil {
+		return err
+	}
+	res, err := j.tx.Exec("UPDATE goals SET status = 'COMPLETED' WHERE id = ? AND status IN ('ACTIVE', 'IDLE')", goalID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		j.summary += " and the goal"
+	}
+	return nil
+}
+
+// reopenGoal makes a completed goal active again once it has work left.
+func reopenGoal(j *journal, goalID int64) error {
+	if err := j.track("goals", goalID); err != nil {
+		return err
+	}
+	_, err := j.tx.Exec("UPDATE goals SET status = 'ACTIVE' WHERE id = ? AND status = 'COMPLETED'", goalID)
+	return err
+}
+
+// ArchiveGoal hides a goal from the switcher without deleting anything. An
+// archived goal stops being the current goal.
+func (s *Store) ArchiveGoal(id int64) error {
+	return s.withJournal("archive_goal", func(j *journal) error {
+		j.describe("Archived goal '%s'", goalName(j.tx, id))
+		if err := j.track("goals", id); err != nil {
+			return err
+		}
+		if err := j.track("app_state", "current_goal_id"); err != nil {
+			return err
+		}
+		if _, err := j.tx.Exec("UPDATE goals SET status = 'ARCHIVED' WHERE id = ?", id); err != nil {
+			return err
+		}
+		_, err := j.tx.Exec("DELETE FROM app_state WHERE key = 'current_goal_id' AND value = CAST(? AS TEXT)", id)
+		return err
+	})
+}
+
+// RestoreGoal brings an archived goal back as IDLE, or COMPLETED when none of
+// its milestones are left to do.
+func (s *Store) RestoreGoal(id int64) error {
+	return s.withJournal("restore_goal", func(j *journal) error {
+		j.describe("Restored goal '%s'", goalName(j.tx, id))
+		if err := j.track("goals", id); err != nil {
+			return err
+		}
+		_, err := j.tx.Exec(`
+			UPDATE goals SET status = CASE
+				WHEN EXISTS (
+					SELECT 1 FROM tasks
+					WHERE goal_id = goals.id AND parent_task_id IS NULL AND status IN ('PENDING', 'IN_PROGRESS')
+				) THEN 'IDLE'
+				ELSE 'COMPLETED'
+			END
+			WHERE id = ? AND status = 'ARCHIVED'`, id)
+		return err
+	})
+}
+
+// DeleteGoal removes a goal; its tasks go with it through ON DELETE CASCADE.
+// A deleted goal stops being the current goal.
+func (s *Store) DeleteGoal(id int64) error {
+	return s.withJournal("delete_goal", func(j *journal) error {
+		j.describe("Deleted goal '%s'", goalName(j.tx, id))
+		if err := j.trackGoalTasks(id); err != nil {
+			return err
+		}
+		if err := j.track("goals", id); err != nil {
+			return err
+		}
+		if err := j.track("app_state", "current_goal_id"); err != nil {
+			return err
+		}
+		if _, err := j.tx.Exec("DELETE FROM goals WHERE id = ?", id); err != nil {
+			return err
+		}
+		_, err := j.tx.Exec("DELETE FROM app_state WHERE key = 'current_goal_id' AND value = CAST(? AS TEXT)", id)
+		return err
+	})
+}
+
+// CreateGoalWithPlan writes a new goal with its planning metadata and whole
+// plan in one transaction and makes it the active goal.
+func (s *Store) CreateGoalWithPlan(goal models.Goal, milestones []ai.Milestone) (int64, error) {
+	var goalID int64
+	err := s.withJournal("create_goal", func(j *journal) error {
+		j.describe("Created goal '%s'", goal.Name)
+		res, err := j.tx.Exec(`
+			INSERT INTO goals (name, status, created_at, context, planner, model, prompt_version, raw_response)
+			VALUES (?, 'ACTIVE', ?, ?, ?, ?, ?, ?)`,
+			goal.Name, time.Now(), goal.Context, goal.Planner, goal.Model, goal.PromptVersion, goal.RawResponse)
+		if err != nil {
+			return err
+		}
+		if goalID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		j.trackNew("goals", goalID)
+
+		if err := insertMilestones(j, goalID, milestones); err != nil {
+			return err
+		}
+		return setActiveGoal(j, goalID)
+	})
+	return goalID, err
+}
+
+// replanKept selects the unfinished tasks of a goal that replanning closes
+// instead of deleting: those with finished work or recorded time at or below
+// them. Deleting them would take that history with them.
+const replanKept = `
+	WITH RECURSIVE below(root, id) AS (
+		SELECT id, id FROM tasks WHERE goal_id = ?
+		UNION ALL
+		SELECT below.root, t.id FROM tasks t JOIN below ON t.parent_task_id = below.id
+	)
+	SELECT DISTINCT below.root FROM below
+	JOIN tasks r ON r.id = below.root
+	JOIN tasks d ON d.id = below.id
+	WHERE r.status IN ('PENDING', 'IN_PROGRESS')
+	AND (d.status IN ('DONE', 'SKIPPED')
+		OR EXISTS (SELECT 1 FROM sessions WHERE task_id = d.id)
+		OR EXISTS (SELECT 1 FROM pomodoros WHERE task_id = d.id))`
+
+// ReplanKept returns the IDs of the pending tasks of a goal that
+// ReplacePendingPlan would close as skipped rather than delete.
+func (s *Store) ReplanKept(goalID int64) (map[int64]bool, error) {
+	rows, err := s.db.Query(replanKept, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	kept := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		kept[id] = true
+	}
+	return kept, rows.Err()
+}
+
+// ReplacePendingPlan drops the pending part of a goal's plan, appends new
+// milestones and records the new planning metadata in one transaction.
+// Finished and skipped tasks stay untouched. A pending task with finished
+// work or recorded time under it is kept as history and closed as skipped
+// with the reason 'replanned', since the new plan supersedes its remaining
+// work.
+func (s *Store) ReplacePendingPlan(goal models.Goal, milestones []ai.Milestone) error {
+	return s.withJournal("replan", func(j *journal) error {
+		j.describe("Replanned '%s'", goal.Name)
+		if err := j.track("goals", goal.ID); err != nil {
+			return err
+		}
+		// Subtasks before the tasks they'd cascade from
+		err := j.trackQuery("tasks", `
+			WITH RECURSIVE plan(id, depth) AS (
+				SELECT id, 0 FROM tasks WHERE goal_id = ? AND parent_task_id IS NULL
+				UNION ALL
+				SELECT t.id, plan.depth + 1 FROM tasks t JOIN plan ON t.parent_task_id = plan.id
+			)
+			SELECT id FROM plan JOIN tasks USING (id)
+			WHERE status IN ('PENDING', 'IN_PROGRESS')
+			ORDER BY depth DESC, id`, goal.ID)
+		if err != nil {
+			return err
+		}
+		if err := j.trackQuery("sessions", "SELECT id FROM sessions WHERE ended_at IS NULL AND task_id IN ("+replanKept+") ORDER BY id", goal.ID); err != nil {
+			return err
+		}
+
+		tx := j.tx
+		_, err = tx.Exec("UPDATE goals SET context = ?, planner = ?, model = ?, prompt_version = ?, raw_response = ? WHERE id = ?",
+			goal.Context, goal.Planner, goal.Model, goal.PromptVersion, goal.RawResponse, goal.ID)
+		if err != nil {
+			return err
+		}
+
+		// Pending tasks with history are closed...
+		if err := stopSessions(tx, time.Now(), "task_id IN ("+replanKept+")", goal.ID); err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE tasks SET status = 'SKIPPED', skip_reason = 'replanned', needs_planning = 0 WHERE id IN ("+replanKept+")", goal.ID)
+		if err != nil {
+			return err
+		}
+
+		// ...and the rest are dropped.
+		_, err = tx.Exec("DELETE FROM tasks WHERE goal_id = ? AND status IN ('PENDING', 'IN_PROGRESS')", goal.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := insertMilestones(j, goal.ID, milestones); err != nil {
+			return err
+		}
+
+		if len(milestones) == 0 {
+			return nil
+		}
+		return reopenGoal(j, goal.ID)
+	})
+}
@@ -0,0 +1,307 @@
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrConflict is returned when undoing or redoing an event would overwrite a
+// row that was changed by something the journal didn't record.
+var ErrConflict = errors.New("changed since")
+
+// keyColumns names the primary key of every journaled table.
+var keyColumns = map[string]string{
+	"goals":     "id",
+	"tasks":     "id",
+	"sessions":  "id",
+	"pomodoros": "id",
+	"app_state": "key",
+}
+
+// timeTables hold the time recorded on tasks. Their rows cascade away with
+// their task, so they're tracked before any task is deleted.
+var timeTables = []string{"sessions", "pomodoros"}
+
+// rowChange is one row's state before and after an event. A nil Before
+// means the row was inserted, a nil After that it was deleted.
+type rowChange struct {
+	Table  string          `json:"table"`
+	Key    any             `json:"key"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// journal collects the rows a mutation touches so they can be written as
+// one CHANGE event when its transaction commits.
+type journal struct {
+	tx      *sql.Tx
+	action  string
+	summary string
+	changes []rowChange
+}
+
+// withJournal runs fn in a transaction and records what it changed as a
+// CHANGE event. fn must track rows before modifying them and should describe
+// what it did; a mutation that changes nothing records nothing.
+func (s *Store) withJournal(action string, fn func(j *journal) error) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		j := &journal{tx: tx, action: action}
+		if err := fn(j); err != nil {
+			return err
+		}
+		return j.record()
+	})
+}
+
+func (j *journal) describe(format string, args ...any) {
+	j.summary = fmt.Sprintf(format, args...)
+}
+
+func (j *journal) tracked(table string, key any) bool {
+	for _, c := range j.changes {
+		if c.Table == table && fmt.Sprint(c.Key) == fmt.Sprint(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// track snapshots a row before it's modified or deleted.
+func (j *journal) track(table string, key any) error {
+	if j.tracked(table, key) {
+		return nil
+	}
+	before, err := snapshot(j.tx, table, key)
+	if err != nil {
+		return err
+	}
+	j.changes = append(j.changes, rowChange{Table: table, Key: key, Before: before})
+	return nil
+}
+
+// trackNew records a row that was just inserted.
+func (j *journal) trackNew(table string, key any) {
+	if !j.tracked(table, key) {
+		j.changes = append(j.changes, rowChange{Table: table, Key: key})
+	}
+}
+
+// trackQuery tracks every row whose key the query returns, in its order.
+// Rows that are deleted must come before the rows they cascade from.
+func (j *journal) trackQuery(table, query string, args ...any) error {
+	rows, err := j.tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	var keys []any
+	for rows.Next() {
+		var key any
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return err
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := j.track(table, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackTaskTime tracks the time recorded on the tasks whose IDs taskQuery
+// returns, ready for them to be deleted.
+func (j *journal) trackTaskTime(taskQuery string, args ...any) error {
+	for _, table := range timeTables {
+		err := j.trackQuery(table, "SELECT id FROM "+table+" WHERE task_id IN ("+taskQuery+") ORDER BY id", args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackGoalTasks tracks every task of a goal, deepest first, and the time
+// recorded on them, ready for the goal to be deleted.
+func (j *journal) trackGoalTasks(goalID int64) error {
+	if err := j.trackTaskTime("SELECT id FROM tasks WHERE goal_id = ?", goalID); err != nil {
+		return err
+	}
+	return j.trackQuery("tasks", `
+		WITH RECURSIVE tree(id, depth) AS (
+			SELECT id, 0 FROM tasks WHERE goal_id = ? AND parent_task_id IS NULL
+			UNION ALL
+			SELECT t.id, tree.depth + 1 FROM tasks t JOIN tree ON t.parent_task_id = tree.id
+		)
+		SELECT id FROM tree ORDER BY depth DESC, id DESC`, goalID)
+}
+
+// record snapshots the tracked rows again and writes the event, leaving out
+// rows that ended up unchanged.
+func (j *journal) record() error {
+	var changes []rowChange
+	for _, c := range j.changes {
+		after, err := snapshot(j.tx, c.Table, c.Key)
+		if err != nil {
+			return err
+		}
+		if sameRow(c.Before, after) {
+			continue
+		}
+		c.After = after
+		changes = append(changes, c)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = j.tx.Exec("INSERT INTO events (created_at, kind, action, summary, changes) VALUES (?, 'CHANGE', ?, ?, ?)",
+		time.Now(), j.action, j.summary, string(data))
+	return err
+}
+
+// columns lists a journaled table's columns.
+func columns(q querier, table string) ([]string, error) {
+	rows, err := q.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+// snapshot returns a row as a JSON object built by SQLite itself, so values
+// round-trip with their stored types, or nil when the row doesn't exist.
+func snapshot(q querier, table string, key any) (json.RawMessage, error) {
+	cols, err := columns(q, table)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]string, len(cols))
+	for i, col := range cols {
+		pairs[i] = fmt.Sprintf("'%s', %s", col, col)
+	}
+
+	var data string
+	err = q.QueryRow(fmt.Sprintf("SELECT json_object(%s) FROM %s WHERE %s = ?",
+		strings.Join(pairs, ", "), table, keyColumns[table]), key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+func isNull(row json.RawMessage) bool {
+	return len(row) == 0 || string(row) == "null"
+}
+
+// sameRow compares two snapshots by value, since re-encoding may change
+// their bytes. Only columns in both count, so snapshots taken before a
+// migration added or dropped columns still match.
+func sameRow(a, b json.RawMessage) bool {
+	if isNull(a) || isNull(b) {
+		return isNull(a) == isNull(b)
+	}
+	var va, vb map[string]any
+	if err := decodeJSON(a, &va); err != nil {
+		return false
+	}
+	if err := decodeJSON(b, &vb); err != nil {
+		return false
+	}
+	for col, v := range va {
+		if w, ok := vb[col]; ok && !reflect.DeepEqual(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// apply moves a row from one snapshot to another: undoing goes from After
+// to Before, redoing from Before to After. It refuses when the row no longer
+// looks like the state it's moving from.
+func (c rowChange) apply(tx *sql.Tx, undo bool) error {
+	from, to := c.Before, c.After
+	if undo {
+		from, to = c.After, c.Before
+	}
+
+	key := c.Key
+	if n, ok := key.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			key = i
+		}
+	}
+	current, err := snapshot(tx, c.Table, key)
+	if err != nil {
+		return err
+	}
+	if !sameRow(current, from) {
+		return fmt.Errorf("%s row %v %w", c.Table, key, ErrConflict)
+	}
+
+	keyCol := keyColumns[c.Table]
+	if isNull(to) {
+		_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", c.Table, keyCol), key)
+		return err
+	}
+
+	// Columns the snapshot doesn't have keep their current or default value
+	var row map[string]any
+	if err := decodeJSON(to, &row); err != nil {
+		return err
+	}
+	all, err := columns(tx, c.Table)
+	if err != nil {
+		return err
+	}
+	var cols, values []string
+	for _, col := range all {
+		if _, ok := row[col]; ok {
+			cols = append(cols, col)
+			values = append(values, fmt.Sprintf("json_extract(v, '$.\"%s\"')", col))
+		}
+	}
+	if isNull(current) {
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM (SELECT ? AS v)",
+			c.Table, strings.Join(cols, ", "), strings.Join(values, ", ")), string(to))
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET (%s) = (SELECT %s FROM (SELECT ? AS v)) WHERE %s = ?",
+		c.Table, strings.Join(cols, ", "), strings.Join(values, ", "), keyCol), string(to), key)
+	return err
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yagnikpt/kairos/internal/git"
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+var ErrProofRequired = errors.New("this goal requires proof of work to check a task off")
+
+// ProofEntries splits the proof of work attached to a task into entries,
+// oldest first.
+func ProofEntries(t *models.Task) []string {
+	if !t.ProofOfWork.Valid {
+		return nil
+	}
+	return strings.Split(t.ProofOfWork.String, "\n")
+}
+
+// addProof appends an entry to the proof of work of a task. Empty proof is
+// ignored.
+func addProof(j *journal, id int64, proof string) error {
+	proof = strings.Join(strings.Fields(proof), " ")
+	if proof == "" {
+		return nil
+	}
+	_, err := j.tx.Exec("UPDATE tasks SET proof_of_work = COALESCE(proof_of_work || char(10), '') || ? WHERE id = ?", proof, id)
+	return err
+}
+
+// CompleteSubtask checks a subtask off like ToggleSubtask, with proof of
+// work attached first so it counts towards the goal's proof policy. A
+// subtask that's already done just gets the proof.
+func (s *Store) CompleteSubtask(id int64, proof string) (milestoneDone bool, err error) {
+	err = s.withJournal("complete_subtask", func(j *journal) error {
+		t, err := trackSubtask(j, id)
+		if err != nil {
+			return err
+		}
+		if err := addProof(j, id, proof); err != nil {
+			return err
+		}
+		if t.Status == "DONE" {
+			j.describe("Added proof of work to '%s'", t.Description)
+			return nil
+		}
+
+		j.describe("Checked '%s'", t.Description)
+		if err := checkSubtask(j, id); err != nil {
+			return err
+		}
+		milestoneDone, err = syncParents(j, t.ParentTaskID.Int64)
+		return err
+	})
+	return milestoneDone, err
+}
+
+// SetProofRequired sets whether a goal's tasks need proof of work to be
+// checked off.
+func (s *Store) SetProofRequired(goalID int64, required bool) error {
+	return s.withJournal("proof_policy", func(j *journal) error {
+		if err := j.track("goals", goalID); err != nil {
+			return err
+		}
+		j.describe("Made proof of work optional for '%s'", goalName(j.tx, goalID))
+		if required {
+			j.describe("Required proof of work for '%s'", goalName(j.tx, goalID))
+		}
+		_, err := j.tx.Exec("UPDATE goals SET proof_required = ? WHERE id = ?", required, goalID)
+		return err
+	})
+}
+
+// AttachCommit records a commit as proof of work, all in one change: on the
+// subtask being worked on, and on the subtasks the commit says it finishes,
+// which are checked off. A subtask worked on that has since been split is
+// left out, with a note in the journal. It returns the subtask the commit
+// was attached to as the one being worked on, if any, and whether a
+// milestone was completed.
+func (s *Store) AttachCommit(c git.Commit) (working *models.Task, milestoneDone bool, err error) {
+	err = s.withJournal("commit", func(j *journal) error {
+		// One line for everything the commit did
+		describe := func(format string, args ...any) {
+			if j.summary != "" {
+				j.summary += "; "
+			}
+			j.summary += fmt.Sprintf(format, args...)
+		}
+
+		var taskID int64
+		err := j.tx.QueryRow("SELECT task_id FROM sessions WHERE ended_at IS NULL ORDER BY id DESC LIMIT 1").Scan(&taskID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if taskID != 0 && !slices.Contains(c.Done, taskID) {
+			working, err = trackSubtask(j, taskID)
+			switch {
+			case errors.Is(err, errSplit):
+				// Its subtasks say which part the commit was for
+				var name string
+				if err := j.tx.QueryRow("SELECT description FROM tasks WHERE id = ?", taskID).Scan(&name); err != nil {
+					return err
+				}
+				describe("Left commit %s off '%s', which was split", c.Short(), name)
+			case err != nil:
+				return err
+			default:
+				if err := addProof(j, taskID, c.String()); err != nil {
+					return err
+				}
+				describe("Attached commit %s to '%s'", c.Short(), working.Description)
+			}
+		}
+
+		for _, id := range c.Done {
+			t, err := trackSubtask(j, id)
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%s names task %d, which doesn't exist", git.DoneTrailer, id)
+			} else if err != nil {
+				return err
+			}
+			if id == taskID {
+				working = t
+			}
+			if err := addProof(j, id, c.String()); err != nil {
+				return err
+			}
+			if t.Status == "DONE" {
+				describe("Attached commit %s to '%s'", c.Short(), t.Description)
+				continue
+			}
+
+			describe("Checked '%s' with commit %s", t.Description, c.Short())
+			if err := checkSubtask(j, id); err != nil {
+				return err
+			}
+			done, err := syncParents(j, t.ParentTaskID.Int64)
+			if err != nil {
+				return err
+			}
+			milestoneDone = milestoneDone || done
+		}
+		return nil
+	})
+	return working, milestoneDone, err
+}
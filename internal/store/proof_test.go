@@ -0,0 +1,154 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/git"
+)
+
+func TestCompleteSubtaskWithProof(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if _, err := s.CompleteSubtask(subtasks[0].ID, "  notes.md \n"); err != nil {
+		t.Fatal(err)
+	}
+	// More proof for a task that's already done
+	if _, err := s.CompleteSubtask(subtasks[0].ID, "https://go.dev/tour"); err != nil {
+		t.Fatal(err)
+	}
+	done, _ := s.Task(subtasks[0].ID)
+	if done.Status != "DONE" {
+		t.Errorf("status = %s, want DONE", done.Status)
+	}
+	if got, want := ProofEntries(done), []string{"notes.md", "https://go.dev/tour"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("proof = %q, want %q", got, want)
+	}
+
+	// Proof is optional by default
+	milestoneDone, err := s.CompleteSubtask(subtasks[1].ID, "")
+	if err != nil || !milestoneDone {
+		t.Errorf("CompleteSubtask without proof = %v, %v; want milestone done", milestoneDone, err)
+	}
+}
+
+func TestProofRequired(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if err := s.SetProofRequired(id, true); err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := s.Goal(id); !g.ProofRequired {
+		t.Fatal("policy not saved")
+	}
+
+	if _, err := s.ToggleSubtask(subtasks[0].ID); !errors.Is(err, ErrProofRequired) {
+		t.Errorf("ToggleSubtask without proof = %v, want ErrProofRequired", err)
+	}
+	if _, err := s.CompleteSubtask(subtasks[0].ID, " "); !errors.Is(err, ErrProofRequired) {
+		t.Errorf("CompleteSubtask with blank proof = %v, want ErrProofRequired", err)
+	}
+	if task, _ := s.Task(subtasks[0].ID); task.Status != "PENDING" || task.ProofOfWork.Valid {
+		t.Errorf("rejected completion changed the task: %+v", task)
+	}
+
+	if _, err := s.CompleteSubtask(subtasks[0].ID, "commit 1a2b3c4 Add tour notes"); err != nil {
+		t.Fatal(err)
+	}
+	// Proof already attached satisfies the policy when checking it off again
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Errorf("re-checking a task with proof: %v", err)
+	}
+}
+
+func TestAttachCommit(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	read, practice := subtasks[0].ID, subtasks[1].ID
+
+	// Nothing is being worked on and nothing is finished
+	if working, _, err := s.AttachCommit(git.Commit{SHA: "aaa1111", Subject: "Notes"}); err != nil || working != nil {
+		t.Fatalf("AttachCommit = %v, %v", working, err)
+	}
+	if events, _ := s.Events(10); len(events) != 1 {
+		t.Errorf("a commit that changed nothing was journaled: %+v", events[0])
+	}
+
+	if err := s.StartSession(read); err != nil {
+		t.Fatal(err)
+	}
+	working, done, err := s.AttachCommit(git.Commit{SHA: "bbb2222", Subject: "Read the tour"})
+	if err != nil || working == nil || working.ID != read || done {
+		t.Fatalf("AttachCommit = %v, %v, %v; want it on Read", working, done, err)
+	}
+	task, _ := s.Task(read)
+	if task.Status != "PENDING" || task.ProofOfWork.String != "commit bbb2222 Read the tour" {
+		t.Errorf("after attaching: %s %q", task.Status, task.ProofOfWork.String)
+	}
+
+	// The trailer closes both subtasks, so the milestone too
+	_, done, err = s.AttachCommit(git.Commit{SHA: "ccc3333", Subject: "Practice", Done: []int64{read, practice}})
+	if err != nil || !done {
+		t.Fatalf("AttachCommit with trailers = %v, %v; want milestone done", done, err)
+	}
+	task, _ = s.Task(read)
+	if got, want := ProofEntries(task), []string{"commit bbb2222 Read the tour", "commit ccc3333 Practice"}; task.Status != "DONE" || !reflect.DeepEqual(got, want) {
+		t.Errorf("Read = %s %q", task.Status, got)
+	}
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still running: %v", err)
+	}
+	events, _ := s.Events(1)
+	if want := "Checked 'Read' with commit ccc3333; Checked 'Practice' with commit ccc3333, completing its milestone"; events[0].Summary != want {
+		t.Errorf("summary = %q, want %q", events[0].Summary, want)
+	}
+
+	if _, _, err := s.AttachCommit(git.Commit{SHA: "ddd4444", Done: []int64{999}}); err == nil {
+		t.Error("a trailer naming a missing task should fail")
+	}
+}
+
+func TestAttachCommitToSplitTask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	read, practice := subtasks[0].ID, subtasks[1].ID
+	if _, err := s.SplitTask(read, &ai.Response{Items: []ai.PlanItem{item("Chapter 1", 10)}}); err != nil {
+		t.Fatal(err)
+	}
+	// A session left running on it from before it was split
+	if _, err := s.db.Exec("INSERT INTO sessions (task_id, started_at) VALUES (?, ?)", read, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The trailers still apply
+	working, _, err := s.AttachCommit(git.Commit{SHA: "aaa1111", Subject: "Practice", Done: []int64{practice}})
+	if err != nil || working != nil {
+		t.Fatalf("AttachCommit = %v, %v; want nothing attached to the split task", working, err)
+	}
+	if task, _ := s.Task(read); task.ProofOfWork.Valid {
+		t.Errorf("split task got proof %q", task.ProofOfWork.String)
+	}
+	if task, _ := s.Task(practice); task.Status != "DONE" {
+		t.Errorf("Practice = %s, want DONE", task.Status)
+	}
+	events, _ := s.Events(1)
+	if want := "Left commit aaa1111 off 'Read', which was split; Checked 'Practice' with commit aaa1111"; events[0].Summary != want {
+		t.Errorf("summary = %q, want %q", events[0].Summary, want)
+	}
+}
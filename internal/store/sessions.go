@@ -0,0 +1,136 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/database"
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+// OpenSession returns the running work session, or ErrNotFound.
+func (s *Store) OpenSession() (*models.Session, error) {
+	var sess models.Session
+	err := s.db.QueryRow("SELECT id, task_id, started_at, ended_at FROM sessions WHERE ended_at IS NULL ORDER BY id DESC LIMIT 1").
+		Scan(&sess.ID, &sess.TaskID, &sess.StartedAt, &sess.EndedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// StartSession starts timing work on a task, stopping whatever was running
+// before. It does nothing if the task is already being timed.
+func (s *Store) StartSession(taskID int64) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		var running int
+		err := tx.QueryRow("SELECT COUNT(*) FROM sessions WHERE ended_at IS NULL AND task_id = ?", taskID).Scan(&running)
+		if err != nil {
+			return err
+		}
+		if running > 0 {
+			return nil
+		}
+
+		now := time.Now()
+		if err := stopSessions(tx, now, "1 = 1"); err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO sessions (task_id, started_at) VALUES (?, ?)", taskID, now)
+		return err
+	})
+}
+
+// StopSession stops the running session, if any.
+func (s *Store) StopSession() error {
+	return s.withTx(func(tx *sql.Tx) error {
+		return stopSessions(tx, time.Now(), "1 = 1")
+	})
+}
+
+// stopSessions ends the running sessions matching cond.
+func stopSessions(q querier, now time.Time, cond string, args ...any) error {
+	_, err := q.Exec("UPDATE sessions SET ended_at = ? WHERE ended_at IS NULL AND "+cond, append([]any{now}, args...)...)
+	return err
+}
+
+// TimeSpent returns how long was spent on each task of a goal, keyed by task
+// ID. A task's time includes its subtasks', and a running session counts up
+// to now.
+func (s *Store) TimeSpent(goalID int64) (map[int64]time.Duration, error) {
+	parents := map[int64]int64{}
+	tasks, err := s.Tasks(goalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if t.ParentTaskID.Valid {
+			parents[t.ID] = t.ParentTaskID.Int64
+		}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT s.task_id, s.started_at, s.ended_at
+		FROM sessions s JOIN tasks t ON t.id = s.task_id
+		WHERE t.goal_id = ?`, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	spent := map[int64]time.Duration{}
+	for rows.Next() {
+		var taskID int64
+		var started time.Time
+		var ended sql.NullTime
+		if err := rows.Scan(&taskID, &started, &ended); err != nil {
+			return nil, err
+		}
+		end := now
+		if ended.Valid {
+			end = ended.Time
+		}
+
+		d := end.Sub(started)
+		for id, ok := taskID, true; ok; id, ok = parents[id] {
+			spent[id] += d
+		}
+	}
+	return spent, rows.Err()
+}
+
+// RecordPomodoro stores a finished work interval for a task.
+func (s *Store) RecordPomodoro(taskID int64, started, ended time.Time) error {
+	_, err := s.db.Exec("INSERT INTO pomodoros (task_id, started_at, ended_at) VALUES (?, ?, ?)", taskID, started, ended)
+	return database.CheckBusy(err)
+}
+
+// Pomodoros counts the finished work intervals of each task of a goal, keyed
+// by task ID.
+func (s *Store) Pomodoros(goalID int64) (map[int64]int, error) {
+	rows, err := s.db.Query(`
+		SELECT p.task_id, COUNT(*)
+		FROM pomodoros p JOIN tasks t ON t.id = p.task_id
+		WHERE t.goal_id = ?
+		GROUP BY p.task_id`, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var taskID int64
+		var n int
+		if err := rows.Scan(&taskID, &n); err != nil {
+			return nil, err
+		}
+		counts[taskID] = n
+	}
+	return counts, rows.Err()
+}
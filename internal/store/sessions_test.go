@@ -0,0 +1,100 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("OpenSession error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.StartSession(subtasks[0].ID); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	first, err := s.OpenSession()
+	if err != nil || first.TaskID != subtasks[0].ID {
+		t.Fatalf("OpenSession = %+v, %v", first, err)
+	}
+
+	// Starting the same task again keeps the session
+	if err := s.StartSession(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := s.OpenSession(); again.ID != first.ID {
+		t.Errorf("restarting the same task opened session %d, want %d", again.ID, first.ID)
+	}
+
+	// Starting another task stops the first
+	if err := s.StartSession(subtasks[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.OpenSession()
+	if err != nil || second.TaskID != subtasks[1].ID {
+		t.Fatalf("OpenSession = %+v, %v", second, err)
+	}
+
+	// Checking the task off stops its session
+	if _, err := s.ToggleSubtask(subtasks[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still running after its task was done: %v", err)
+	}
+
+	if err := s.StartSession(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StopSession(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still running after StopSession: %v", err)
+	}
+}
+
+func TestTimeSpent(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	start := time.Now().Add(-2 * time.Hour)
+	sessions := []struct {
+		taskID int64
+		mins   int
+	}{
+		{subtasks[0].ID, 20},
+		{subtasks[0].ID, 5},
+		{subtasks[1].ID, 40},
+	}
+	for _, sess := range sessions {
+		_, err := s.db.Exec("INSERT INTO sessions (task_id, started_at, ended_at) VALUES (?, ?, ?)",
+			sess.taskID, start, start.Add(time.Duration(sess.mins)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spent, err := s.TimeSpent(id)
+	if err != nil {
+		t.Fatalf("TimeSpent: %v", err)
+	}
+	want := map[int64]time.Duration{
+		subtasks[0].ID: 25 * time.Minute,
+		subtasks[1].ID: 40 * time.Minute,
+		milestone.ID:   65 * time.Minute,
+	}
+	for taskID, d := range want {
+		if spent[taskID] != d {
+			t.Errorf("spent on task %d = %v, want %v", taskID, spent[taskID], d)
+		}
+	}
+}
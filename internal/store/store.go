@@ -0,0 +1,49 @@
+// Package store is the only place that knows the database schema. Commands
+// and the TUI go through its typed methods and work with models types.
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/yagnikpt/kairos/internal/database"
+)
+
+var (
+	// ErrNotFound is returned when a goal or task doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrNoActiveGoal is returned when no current goal is selected.
+	ErrNoActiveGoal = errors.New("no active goal selected")
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx, so read helpers can run
+// inside or outside a transaction.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// withTx runs fn in a transaction, committing if it returns nil. The write
+// lock is taken up front, so contention surfaces as database.ErrBusy before
+// any work is done.
+func (s *Store) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return database.CheckBusy(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return database.CheckBusy(err)
+	}
+	return database.CheckBusy(tx.Commit())
+}
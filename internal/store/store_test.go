@@ -0,0 +1,490 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/database"
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := database.InitDB(database.MemoryPath)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db)
+}
+
+func item(title string, mins int) ai.PlanItem {
+	return ai.PlanItem{Title: title, EstimatedDurationMins: mins}
+}
+
+// createGoal saves a goal with two milestones: the first has two subtasks,
+// the second none.
+func createGoal(t *testing.T, s *Store, name string) int64 {
+	t.Helper()
+	id, err := s.CreateGoalWithPlan(models.Goal{Name: name}, []ai.Milestone{
+		{PlanItem: item("Basics", 60), Subtasks: []ai.PlanItem{item("Read", 20), item("Practice", 40)}},
+		{PlanItem: item("Advanced", 90)},
+	})
+	if err != nil {
+		t.Fatalf("CreateGoalWithPlan: %v", err)
+	}
+	return id
+}
+
+func TestCreateGoalWithPlan(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	active, err := s.ActiveGoal()
+	if err != nil {
+		t.Fatalf("ActiveGoal: %v", err)
+	}
+	if active.ID != id || active.Name != "Learn Go" || active.Status != "ACTIVE" {
+		t.Errorf("active goal = %+v, want the new goal", active)
+	}
+
+	tasks, err := s.Tasks(id)
+	if err != nil {
+		t.Fatalf("Tasks: %v", err)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("got %d tasks, want 4", len(tasks))
+	}
+
+	planning, err := s.MilestonesNeedingPlanning(id)
+	if err != nil {
+		t.Fatalf("MilestonesNeedingPlanning: %v", err)
+	}
+	if len(planning) != 1 || planning[0].Description != "Advanced" {
+		t.Errorf("milestones needing planning = %+v, want [Advanced]", planning)
+	}
+}
+
+func TestActiveGoalNone(t *testing.T) {
+	s := newTestStore(t)
+	if _, err := s.ActiveGoal(); !errors.Is(err, ErrNoActiveGoal) {
+		t.Errorf("ActiveGoal() error = %v, want ErrNoActiveGoal", err)
+	}
+}
+
+func TestFindGoal(t *testing.T) {
+	s := newTestStore(t)
+	first := createGoal(t, s, "Learn Go")
+	second := createGoal(t, s, "Ship v2")
+
+	tests := []struct {
+		ref  string
+		want int64
+	}{
+		{"", second},
+		{"learn go", first},
+		{"Ship v2", second},
+	}
+	for _, tt := range tests {
+		g, err := s.FindGoal(tt.ref)
+		if err != nil {
+			t.Errorf("FindGoal(%q): %v", tt.ref, err)
+			continue
+		}
+		if g.ID != tt.want {
+			t.Errorf("FindGoal(%q) = %d, want %d", tt.ref, g.ID, tt.want)
+		}
+	}
+
+	if g, err := s.FindGoal("1"); err != nil || g.ID != first {
+		t.Errorf("FindGoal(\"1\") = %v, %v; want goal %d", g, err, first)
+	}
+	if _, err := s.FindGoal("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindGoal(\"nope\") error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSetActiveGoal(t *testing.T) {
+	s := newTestStore(t)
+	first := createGoal(t, s, "Learn Go")
+	second := createGoal(t, s, "Ship v2")
+
+	if err := s.SetActiveGoal(first); err != nil {
+		t.Fatalf("SetActiveGoal: %v", err)
+	}
+	g, err := s.Goal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Status != "ACTIVE" {
+		t.Errorf("first goal status = %s, want ACTIVE", g.Status)
+	}
+	g, err = s.Goal(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Status != "IDLE" {
+		t.Errorf("second goal status = %s, want IDLE", g.Status)
+	}
+}
+
+func TestToggleSubtaskCompletesMilestone(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	milestone, err := s.NextMilestone(id)
+	if err != nil {
+		t.Fatalf("NextMilestone: %v", err)
+	}
+	if milestone.Description != "Basics" {
+		t.Fatalf("next milestone = %q, want Basics", milestone.Description)
+	}
+	subtasks, err := s.Subtasks(milestone.ID)
+	if err != nil || len(subtasks) != 2 {
+		t.Fatalf("Subtasks = %v, %v; want 2 subtasks", subtasks, err)
+	}
+
+	done, err := s.ToggleSubtask(subtasks[0].ID)
+	if err != nil || done {
+		t.Fatalf("first toggle = %v, %v; want milestone still open", done, err)
+	}
+	if m, _ := s.Task(milestone.ID); m.Status != "IN_PROGRESS" {
+		t.Errorf("milestone status = %s, want IN_PROGRESS", m.Status)
+	}
+
+	done, err = s.ToggleSubtask(subtasks[1].ID)
+	if err != nil || !done {
+		t.Fatalf("second toggle = %v, %v; want milestone done", done, err)
+	}
+	next, err := s.NextMilestone(id)
+	if err != nil || next.Description != "Advanced" {
+		t.Errorf("NextMilestone after completion = %v, %v; want Advanced", next, err)
+	}
+
+	// Unchecking reopens the milestone
+	if done, err := s.ToggleSubtask(subtasks[1].ID); err != nil || done {
+		t.Fatalf("untoggle = %v, %v", done, err)
+	}
+	if m, _ := s.Task(milestone.ID); m.Status != "IN_PROGRESS" {
+		t.Errorf("milestone status after untoggle = %s, want IN_PROGRESS", m.Status)
+	}
+
+	if _, err := s.ToggleSubtask(milestone.ID); err == nil {
+		t.Error("toggling a milestone should fail")
+	}
+}
+
+func TestSkipSubtask(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartSession(subtasks[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The skipped subtask no longer blocks its milestone
+	done, err := s.SkipSubtask(subtasks[1].ID, "Covered at work")
+	if err != nil || !done {
+		t.Fatalf("SkipSubtask = %v, %v; want milestone done", done, err)
+	}
+	skipped, _ := s.Task(subtasks[1].ID)
+	if skipped.Status != "SKIPPED" || skipped.SkipReason.String != "Covered at work" {
+		t.Errorf("skipped task = %s %q", skipped.Status, skipped.SkipReason.String)
+	}
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still running after skip: %v", err)
+	}
+
+	if err := s.UnskipSubtask(subtasks[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	unskipped, _ := s.Task(subtasks[1].ID)
+	if unskipped.Status != "PENDING" || unskipped.SkipReason.Valid {
+		t.Errorf("unskipped task = %s %q", unskipped.Status, unskipped.SkipReason.String)
+	}
+	if m, _ := s.Task(milestone.ID); m.Status != "IN_PROGRESS" {
+		t.Errorf("milestone status after unskip = %s, want IN_PROGRESS", m.Status)
+	}
+}
+
+func TestDeferSubtask(t *testing.T) {
+	s := newTestStore(t)
+	id, err := s.CreateGoalWithPlan(models.Goal{Name: "Learn Go"}, []ai.Milestone{
+		{PlanItem: item("Basics", 60), Subtasks: []ai.PlanItem{item("Read", 10), item("Practice", 20), item("Quiz", 30)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+
+	for _, i := range []int{0, 1} {
+		if err := s.DeferSubtask(subtasks[i].ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	subtasks, _ = s.Subtasks(milestone.ID)
+	var got []string
+	for _, sub := range subtasks {
+		got = append(got, sub.Description)
+	}
+	if want := []string{"Quiz", "Read", "Practice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order after deferring = %v, want %v", got, want)
+	}
+}
+
+func TestNextMilestoneNone(t *testing.T) {
+	s := newTestStore(t)
+	id, err := s.CreateGoalWithPlan(models.Goal{Name: "Empty"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.NextMilestone(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("NextMilestone error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPlanMilestones(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	planning, err := s.MilestonesNeedingPlanning(id)
+	if err != nil || len(planning) != 1 {
+		t.Fatalf("MilestonesNeedingPlanning = %v, %v", planning, err)
+	}
+	results := []ai.SubTaskResult{{Subtasks: []ai.PlanItem{item("Generics", 30)}, Raw: `[{"title": "Generics"}]`}}
+	planned, err := s.PlanMilestones(id, []int64{planning[0].ID}, results)
+	if err != nil || planned != 1 {
+		t.Fatalf("PlanMilestones = %d, %v; want 1", planned, err)
+	}
+
+	if planning, _ := s.MilestonesNeedingPlanning(id); len(planning) != 0 {
+		t.Errorf("still needing planning: %+v", planning)
+	}
+	subtasks, err := s.Subtasks(planning[0].ID)
+	if err != nil || len(subtasks) != 1 || subtasks[0].Description != "Generics" {
+		t.Errorf("Subtasks = %+v, %v; want [Generics]", subtasks, err)
+	}
+	if m, _ := s.Task(planning[0].ID); m.RawResponse.String != results[0].Raw {
+		t.Errorf("raw response = %q, want %q", m.RawResponse.String, results[0].Raw)
+	}
+}
+
+func TestRemainingMins(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	milestone, err := s.NextMilestone(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leaves are Read (20), Practice (40) and the unplanned Advanced (90)
+	if mins, err := s.RemainingMins(milestone.ID); err != nil || mins != 60 {
+		t.Errorf("RemainingMins = %d, %v; want 60", mins, err)
+	}
+	if mins, err := s.GoalRemainingMins(id); err != nil || mins != 150 {
+		t.Errorf("GoalRemainingMins = %d, %v; want 150", mins, err)
+	}
+
+	subtasks, _ := s.Subtasks(milestone.ID)
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if mins, err := s.RemainingMins(milestone.ID); err != nil || mins != 40 {
+		t.Errorf("RemainingMins after toggle = %d, %v; want 40", mins, err)
+	}
+}
+
+func TestMilestoneWithoutEstimate(t *testing.T) {
+	s := newTestStore(t)
+	id, err := s.CreateGoalWithPlan(models.Goal{Name: "Learn Go"}, []ai.Milestone{{PlanItem: ai.PlanItem{Title: "Basics"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	milestone, err := s.NextMilestone(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if milestone.EstimatedDurationMins.Valid {
+		t.Errorf("estimate = %d, want none", milestone.EstimatedDurationMins.Int64)
+	}
+}
+
+func TestReplacePendingPlan(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	milestone, _ := s.NextMilestone(id)
+	subtasks, _ := s.Subtasks(milestone.ID)
+	if _, err := s.ToggleSubtask(subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	goal, err := s.Goal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.ReplacePendingPlan(*goal, []ai.Milestone{
+		{PlanItem: item("Projects", 120), Subtasks: []ai.PlanItem{item("CLI tool", 120)}},
+	})
+	if err != nil {
+		t.Fatalf("ReplacePendingPlan: %v", err)
+	}
+
+	tasks, err := s.Tasks(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.Description+":"+task.Status)
+	}
+	// Basics keeps its finished subtask and is closed; Advanced is dropped
+	want := []string{"Basics:SKIPPED", "Read:DONE", "Projects:PENDING", "CLI tool:PENDING"}
+	if len(got) != len(want) {
+		t.Fatalf("tasks = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tasks = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestReplanKeepsTrackedTime(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+	tasks, _ := s.Tasks(id)
+	advanced := tasks[len(tasks)-1]
+	if err := s.StartSession(advanced.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, err := s.ReplanKept(id)
+	if err != nil {
+		t.Fatalf("ReplanKept: %v", err)
+	}
+	if !kept[advanced.ID] || len(kept) != 1 {
+		t.Errorf("ReplanKept = %v, want only #%d", kept, advanced.ID)
+	}
+
+	goal, _ := s.Goal(id)
+	if err := s.ReplacePendingPlan(*goal, []ai.Milestone{{PlanItem: item("Projects", 120)}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Task(advanced.ID)
+	if err != nil {
+		t.Fatalf("worked-on milestone was deleted: %v", err)
+	}
+	if got.Status != "SKIPPED" || got.SkipReason.String != "replanned" {
+		t.Errorf("milestone = %s (%s), want SKIPPED (replanned)", got.Status, got.SkipReason.String)
+	}
+	if _, err := s.OpenSession(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still running after replan: %v", err)
+	}
+	if spent, _ := s.TimeSpent(id); spent[advanced.ID] <= 0 {
+		t.Errorf("time spent = %v, want the recorded session", spent[advanced.ID])
+	}
+}
+
+func TestArchiveAndRestoreGoal(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	if err := s.ArchiveGoal(id); err != nil {
+		t.Fatalf("ArchiveGoal: %v", err)
+	}
+	if g, _ := s.Goal(id); g.Status != "ARCHIVED" {
+		t.Errorf("status after archive = %s, want ARCHIVED", g.Status)
+	}
+	if _, err := s.ActiveGoal(); !errors.Is(err, ErrNoActiveGoal) {
+		t.Errorf("archived goal is still current: %v", err)
+	}
+	if tasks, _ := s.Tasks(id); len(tasks) != 4 {
+		t.Errorf("archiving removed tasks: %d left, want 4", len(tasks))
+	}
+
+	if err := s.RestoreGoal(id); err != nil {
+		t.Fatalf("RestoreGoal: %v", err)
+	}
+	if g, _ := s.Goal(id); g.Status != "IDLE" {
+		t.Errorf("status after restore = %s, want IDLE", g.Status)
+	}
+
+	// A goal with nothing left to do comes back completed
+	done, err := s.CreateGoalWithPlan(models.Goal{Name: "Done"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ArchiveGoal(done); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RestoreGoal(done); err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := s.Goal(done); g.Status != "COMPLETED" {
+		t.Errorf("status after restore = %s, want COMPLETED", g.Status)
+	}
+}
+
+func TestDeleteGoal(t *testing.T) {
+	s := newTestStore(t)
+	id := createGoal(t, s, "Learn Go")
+
+	if err := s.DeleteGoal(id); err != nil {
+		t.Fatalf("DeleteGoal: %v", err)
+	}
+	if _, err := s.Goal(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Goal after delete error = %v, want ErrNotFound", err)
+	}
+	goals, err := s.Goals()
+	if err != nil || len(goals) != 0 {
+		t.Errorf("Goals = %v, %v; want none", goals, err)
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("%d tasks left after deleting their goal, want 0", count)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	s := newTestStore(t)
+	createGoal(t, s, "Learn Go")
+
+	problems, err := s.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("problems on a clean database: %v", problems)
+	}
+
+	// Recreate what deleting a goal did before foreign keys were enforced
+	if _, err := s.db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.db.Exec("DELETE FROM goals"); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err = s.CheckIntegrity()
+	if err != nil {
+		t.Fatalf("CheckIntegrity: %v", err)
+	}
+	// foreign keys off, four orphaned tasks and a dangling current goal
+	if len(problems) != 6 {
+		t.Errorf("got %d problems, want 6: %v", len(problems), problems)
+	}
+}
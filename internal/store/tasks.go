@@ -0,0 +1,383 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/models"
+)
+
+const taskColumns = `id, goal_id, parent_task_id, description, status, estimated_duration_mins, proof_of_work, skip_reason, needs_planning, raw_response`
+
+func scanTask(row interface{ Scan(...any) error }) (*models.Task, error) {
+	var t models.Task
+	err := row.Scan(&t.ID, &t.GoalID, &t.ParentTaskID, &t.Description, &t.Status, &t.EstimatedDurationMins, &t.ProofOfWork, &t.SkipReason, &t.NeedsPlanning, &t.RawResponse)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func queryTasks(q querier, query string, args ...any) ([]models.Task, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+// Task returns the task with the given ID.
+func (s *Store) Task(id int64) (*models.Task, error) {
+	return scanTask(s.db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+}
+
+// Tasks returns every task of a goal in plan order: each milestone followed
+// by its subtasks.
+func (s *Store) Tasks(goalID int64) ([]models.Task, error) {
+	return queryTasks(s.db, `
+		WITH RECURSIVE plan(id, path) AS (
+			SELECT id, printf('%06d.%010d', position, id) FROM tasks WHERE goal_id = ? AND parent_task_id IS NULL
+			UNION ALL
+			SELECT t.id, plan.path || '/' || printf('%06d.%010d', t.position, t.id)
+			FROM tasks t JOIN plan ON t.parent_task_id = plan.id
+		)
+		SELECT `+taskColumns+` FROM tasks JOIN plan USING (id) ORDER BY plan.path`, goalID)
+}
+
+// Subtasks returns the children of a task in plan order.
+func (s *Store) Subtasks(parentID int64) ([]models.Task, error) {
+	return queryTasks(s.db, "SELECT "+taskColumns+" FROM tasks WHERE parent_task_id = ? ORDER BY position, id", parentID)
+}
+
+// NextMilestone returns the first milestone of a goal that isn't finished,
+// or ErrNotFound when every milestone is done.
+func (s *Store) NextMilestone(goalID int64) (*models.Task, error) {
+	return scanTask(s.db.QueryRow(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE goal_id = ? AND parent_task_id IS NULL AND status IN ('PENDING', 'IN_PROGRESS')
+		ORDER BY position, id LIMIT 1`, goalID))
+}
+
+// MilestonesNeedingPlanning returns milestones whose subtasks still have to
+// be generated.
+func (s *Store) MilestonesNeedingPlanning(goalID int64) ([]models.Task, error) {
+	return queryTasks(s.db, `
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE goal_id = ? AND parent_task_id IS NULL AND needs_planning = 1
+		ORDER BY position, id`, goalID)
+}
+
+// PlanMilestones stores generated subtasks, with the planner reply they came
+// from, for milestones that needed planning and clears their flag, all in one
+// transaction. results is indexed like milestoneIDs; milestones whose result
+// failed or has no subtasks keep the flag. It returns how many milestones
+// were planned.
+func (s *Store) PlanMilestones(goalID int64, milestoneIDs []int64, results []ai.SubTaskResult) (int, error) {
+	planned := 0
+	err := s.withJournal("plan_milestones", func(j *journal) error {
+		for i, milestoneID := range milestoneIDs {
+			r := results[i]
+			if r.Err != nil || len(r.Subtasks) == 0 {
+				continue
+			}
+			if err := j.track("tasks", milestoneID); err != nil {
+				return err
+			}
+			if _, err := insertSubtasks(j, goalID, milestoneID, r.Subtasks); err != nil {
+				return err
+			}
+			if _, err := j.tx.Exec("UPDATE tasks SET needs_planning = 0, raw_response = NULLIF(?, '') WHERE id = ?", r.Raw, milestoneID); err != nil {
+				return err
+			}
+			planned++
+		}
+		j.describe("Planned %d milestone(s) of '%s'", planned, goalName(j.tx, goalID))
+		return nil
+	})
+	return planned, err
+}
+
+// ToggleSubtask flips a subtask between DONE and PENDING and keeps the tasks
+// above it in step: DONE once every task under them is done or skipped,
+// IN_PROGRESS otherwise. It reports whether the milestone was completed by
+// this toggle.
+// Checking a subtask off stops its running work session, and fails with
+// ErrProofRequired if its goal requires proof of work and none is attached.
+func (s *Store) ToggleSubtask(id int64) (milestoneDone bool, err error) {
+	err = s.withJournal("toggle_subtask", func(j *journal) error {
+		t, err := trackSubtask(j, id)
+		if err != nil {
+			return err
+		}
+
+		if t.Status == "DONE" {
+			j.describe("Unchecked '%s'", t.Description)
+			if _, err := j.tx.Exec("UPDATE tasks SET status = 'PENDING' WHERE id = ?", id); err != nil {
+				return err
+			}
+		} else {
+			j.describe("Checked '%s'", t.Description)
+			if err := checkSubtask(j, id); err != nil {
+				return err
+			}
+		}
+
+		milestoneDone, err = syncParents(j, t.ParentTaskID.Int64)
+		return err
+	})
+	return milestoneDone, err
+}
+
+// checkSubtask marks a subtask DONE, as long as its goal's proof policy is
+// met, and ends the time spent on it.
+func checkSubtask(j *journal, id int64) error {
+	var required, proven bool
+	err := j.tx.QueryRow(`
+		SELECT g.proof_required, t.proof_of_work IS NOT NULL
+		FROM tasks t JOIN goals g ON g.id = t.goal_id
+		WHERE t.id = ?`, id).Scan(&required, &proven)
+	if err != nil {
+		return err
+	}
+	if required && !proven {
+		return ErrProofRequired
+	}
+
+	if _, err := j.tx.Exec("UPDATE tasks SET status = 'DONE', skip_reason = NULL WHERE id = ?", id); err != nil {
+		return err
+	}
+	return stopSessions(j.tx, time.Now(), "task_id = ?", id)
+}
+
+// SkipSubtask marks a subtask SKIPPED, with an optional reason, and stops
+// its work session. Skipped subtasks count as resolved, so skipping the last
+// open one completes the milestone, which is reported like ToggleSubtask.
+func (s *Store) SkipSubtask(id int64, reason string) (milestoneDone bool, err error) {
+	err = s.withJournal("skip_subtask", func(j *journal) error {
+		t, err := trackSubtask(j, id)
+		if err != nil {
+			return err
+		}
+		if t.Status == "SKIPPED" {
+			return nil
+		}
+
+		j.describe("Skipped '%s'", t.Description)
+		if reason != "" {
+			j.describe("Skipped '%s': %s", t.Description, reason)
+		}
+		_, err = j.tx.Exec("UPDATE tasks SET status = 'SKIPPED', skip_reason = NULLIF(?, '') WHERE id = ?", reason, id)
+		if err != nil {
+			return err
+		}
+		if err := stopSessions(j.tx, time.Now(), "task_id = ?", id); err != nil {
+			return err
+		}
+
+		milestoneDone, err = syncParents(j, t.ParentTaskID.Int64)
+		return err
+	})
+	return milestoneDone, err
+}
+
+// UnskipSubtask puts a skipped subtask back to PENDING, reopening its
+// milestone if needed.
+func (s *Store) UnskipSubtask(id int64) error {
+	return s.withJournal("unskip_subtask", func(j *journal) error {
+		t, err := trackSubtask(j, id)
+		if err != nil {
+			return err
+		}
+		if t.Status != "SKIPPED" {
+			return nil
+		}
+
+		j.describe("Unskipped '%s'", t.Description)
+		if _, err := j.tx.Exec("UPDATE tasks SET status = 'PENDING', skip_reason = NULL WHERE id = ?", id); err != nil {
+			return err
+		}
+		_, err = syncParents(j, t.ParentTaskID.Int64)
+		return err
+	})
+}
+
+// DeferSubtask moves a subtask to the end of its siblings and stops its
+// work session.
+func (s *Store) DeferSubtask(id int64) error {
+	return s.withJournal("defer_subtask", func(j *journal) error {
+		t, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		if !t.ParentTaskID.Valid {
+			return errors.New("only subtasks can be changed this way")
+		}
+
+		j.describe("Deferred '%s'", t.Description)
+		if err := moveTask(j, t, math.MaxInt); err != nil {
+			return err
+		}
+		return stopSessions(j.tx, time.Now(), "task_id = ?", id)
+	})
+}
+
+// errSplit is returned for tasks worked on through their subtasks.
+var errSplit = errors.New("was split; work through its subtasks instead")
+
+// trackSubtask loads a subtask without subtasks of its own and tracks it.
+func trackSubtask(j *journal, id int64) (*models.Task, error) {
+	t, err := scanTask(j.tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if !t.ParentTaskID.Valid {
+		return nil, errors.New("only subtasks can be changed this way")
+	}
+	var split bool
+	if err := j.tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE parent_task_id = ?)", id).Scan(&split); err != nil {
+		return nil, err
+	}
+	if split {
+		return nil, fmt.Errorf("'%s' %w", t.Description, errSplit)
+	}
+	if err := j.track("tasks", id); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncParents keeps a task and the ones above it in step with their
+// subtasks: DONE once every one is done or skipped, IN_PROGRESS otherwise.
+// It reports whether the milestone at the top was just completed, which
+// completes the goal too when it was the last one. A reopened milestone
+// reopens its goal.
+func syncParents(j *journal, id int64) (milestoneDone bool, err error) {
+	for id != 0 {
+		if err := j.track("tasks", id); err != nil {
+			return false, err
+		}
+		var open int
+		err := j.tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE parent_task_id = ? AND status NOT IN ('DONE', 'SKIPPED')", id).Scan(&open)
+		if err != nil {
+			return false, err
+		}
+
+		var completed bool
+		if open > 0 {
+			_, err = j.tx.Exec("UPDATE tasks SET status = 'IN_PROGRESS' WHERE id = ? AND status IN ('PENDING', 'DONE')", id)
+		} else {
+			var res sql.Result
+			res, err = j.tx.Exec("UPDATE tasks SET status = 'DONE' WHERE id = ? AND status != 'DONE'", id)
+			if err == nil {
+				n, _ := res.RowsAffected()
+				completed = n > 0
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+
+		var goalID int64
+		var parent sql.NullInt64
+		if err := j.tx.QueryRow("SELECT goal_id, parent_task_id FROM tasks WHERE id = ?", id).Scan(&goalID, &parent); err != nil {
+			return false, err
+		}
+		if !parent.Valid && completed {
+			j.summary += ", completing its milestone"
+			return true, completeGoal(j, id)
+		}
+		if !parent.Valid && open > 0 {
+			return false, reopenGoal(j, goalID)
+		}
+		id = parent.Int64
+	}
+	return false, nil
+}
+
+// RemainingMins sums the estimates of the unfinished leaf tasks under a task,
+// including the task itself when it has no children.
+func (s *Store) RemainingMins(taskID int64) (int, error) {
+	var mins int
+	err := s.db.QueryRow(`
+		WITH RECURSIVE below(id) AS (
+			SELECT ?
+			UNION ALL
+			SELECT t.id FROM tasks t JOIN below ON t.parent_task_id = below.id
+		)
+		SELECT COALESCE(SUM(estimated_duration_mins), 0)
+		FROM tasks t JOIN below USING (id)
+		WHERE status IN ('PENDING', 'IN_PROGRESS')
+		AND NOT EXISTS (SELECT 1 FROM tasks c WHERE c.parent_task_id = t.id)`, taskID).Scan(&mins)
+	return mins, err
+}
+
+// GoalRemainingMins sums the estimates of every unfinished leaf task of a
+// goal.
+func (s *Store) GoalRemainingMins(goalID int64) (int, error) {
+	var mins int
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(estimated_duration_mins), 0)
+		FROM tasks t
+		WHERE goal_id = ? AND status IN ('PENDING', 'IN_PROGRESS')
+		AND NOT EXISTS (SELECT 1 FROM tasks c WHERE c.parent_task_id = t.id)`, goalID).Scan(&mins)
+	return mins, err
+}
+
+// insertMilestones appends milestones and their subtasks to a goal.
+// Milestones without subtasks are flagged as needing planning.
+func insertMilestones(j *journal, goalID int64, milestones []ai.Milestone) error {
+	for _, m := range milestones {
+		res, err := j.tx.Exec("INSERT INTO tasks (goal_id, description, status, estimated_duration_mins, needs_planning, raw_response, position) VALUES (?, ?, 'PENDING', ?, ?, NULLIF(?, ''), "+nextPosition+")",
+			goalID, m.Title, nullMins(m.EstimatedDurationMins), m.NeedsPlanning || len(m.Subtasks) == 0, m.RawResponse, goalID, nil)
+		if err != nil {
+			return err
+		}
+		milestoneID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		j.trackNew("tasks", milestoneID)
+		if _, err := insertSubtasks(j, goalID, milestoneID, m.Subtasks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertSubtasks appends subtasks to a task and returns their IDs.
+func insertSubtasks(j *journal, goalID, parentID int64, subtasks []ai.PlanItem) ([]int64, error) {
+	var ids []int64
+	for _, sub := range subtasks {
+		res, err := j.tx.Exec("INSERT INTO tasks (goal_id, parent_task_id, description, status, estimated_duration_mins, position) VALUES (?, ?, ?, 'PENDING', ?, "+nextPosition+")",
+			goalID, parentID, sub.Title, nullMins(sub.EstimatedDurationMins), goalID, parentID)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		j.trackNew("tasks", id)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -1,167 +1,907 @@
 package tui
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yagnikpt/kairos/internal/ai"
 	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/git"
 	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/store"
 	"github.com/yagnikpt/kairos/internal/ui"
 )
 
-func RunFocusMode(a *app.App, goalID int64) error {
-	// Clear screen
-	fmt.Print("\033[H\033[2J")
+type focusKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Pick     key.Binding
+	Check    key.Binding
+	Skip     key.Binding
+	Defer    key.Binding
+	Unskip   key.Binding
+	Add      key.Binding
+	Edit     key.Binding
+	Delete   key.Binding
+	MoveUp   key.Binding
+	MoveDown key.Binding
+	Split    key.Binding
+	Tree     key.Binding
+	Chill    key.Binding
+	Quit     key.Binding
+	Help     key.Binding
+}
+
+func (k focusKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Pick, k.Check, k.Skip, k.Tree, k.Chill, k.Quit, k.Help}
+}
+
+func (k focusKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.Pick, k.Check, k.Skip, k.Defer, k.Unskip},
+		{k.Add, k.Edit, k.Delete, k.MoveUp, k.MoveDown, k.Split},
+		{k.Tree},
+		{k.Chill, k.Quit, k.Help},
+	}
+}
+
+var focusKeys = focusKeyMap{
+	Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Pick:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "start/finish")),
+	Check:    key.NewBinding(key.WithKeys("x", " "), key.WithHelp("x", "check/uncheck")),
+	Skip:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "skip")),
+	Defer:    key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "defer to the end")),
+	Unskip:   key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "unskip")),
+	Add:      key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "add subtask")),
+	Edit:     key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "rename")),
+	Delete:   key.NewBinding(key.WithKeys("delete", "backspace"), key.WithHelp("del", "delete")),
+	MoveUp:   key.NewBinding(key.WithKeys("K"), key.WithHelp("K", "move up")),
+	MoveDown: key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "move down")),
+	Split:    key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "explode into subtasks")),
+	Tree:     key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "goal tree")),
+	Chill:    key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "I'm exhausted")),
+	Quit:     key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
+	Help:     key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+}
+
+// timeLabel puts a task's estimate and the time actually spent side by side,
+// e.g. "25m" or "25m, spent 40m, 1 pomodoro".
+func timeLabel(t *models.Task, spent time.Duration, pomodoros int) string {
+	label := "?"
+	if t.EstimatedDurationMins.Valid {
+		label = ui.FormatDuration(int(t.EstimatedDurationMins.Int64))
+	}
+	if spent >= time.Minute {
+		label += ", spent " + ui.FormatDuration(int(spent.Minutes()))
+	}
+	switch {
+	case pomodoros == 1:
+		label += ", 1 pomodoro"
+	case pomodoros > 1:
+		label += fmt.Sprintf(", %d pomodoros", pomodoros)
+	}
+	return label
+}
+
+func newAnswerInput() textinput.Model {
+	input := textinput.New()
+	input.CharLimit = 500
+	input.Width = 60
+	return input
+}
+
+// proofEntry turns what was typed as proof of work into an entry. Paths of
+// existing files are made absolute so they still resolve from elsewhere.
+func proofEntry(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	if _, err := os.Stat(s); err == nil {
+		if abs, err := filepath.Abs(s); err == nil {
+			return abs
+		}
+	}
+	return s
+}
+
+// question is what the input line is asking about the task under the
+// cursor.
+type question int
+
+const (
+	noQuestion question = iota
+	askSkipReason
+	askProof
+	askNewTask // Description of a task to add under the target, or a milestone if there's none
+	askRename
+	askDelete // y/N, without the input line
+)
+
+func statusLine(label, value string) string {
+	return fmt.Sprintf("%s %s", ui.SubtitleStyle.Render(label), ui.StatusStyle.Render(value))
+}
+
+type focusModel struct {
+	app    *app.App
+	goalID int64
+	timer  *Pomodoro
+	now    time.Time
+
+	// Milestone picked in the tree, 0 to follow the first unfinished one
+	pinnedID int64
 
-	// Get Goal Name
-	var goalName string
-	var goalStatus string
-	err := a.DB.QueryRow("SELECT name, status FROM goals WHERE id = ?", goalID).Scan(&goalName, &goalStatus)
+	// Loaded from the store after every change
+	goal          *models.Goal
+	milestone     *models.Task
+	subtasks      []models.Task
+	session       *models.Session
+	milestoneMins int
+	goalMins      int
+	spent         map[int64]time.Duration
+	pomodoros     map[int64]int
+
+	tree     goalTree
+	browsing bool // Showing the tree instead of the milestone
+
+	planner     ai.Planner         // Built the first time a task is exploded
+	splitting   *models.Task       // Task the planner is breaking down
+	cancelSplit context.CancelFunc // Gives up on splitting, e.g. on quit
+
+	asking question
+	target *models.Task // Task the question is about
+	input  textinput.Model
+
+	cursor int
+	width  int
+	height int
+	status string
+	help   help.Model
+
+	farewell func() // Printed once the program exits
+	err      error
+}
+
+// load refreshes everything shown from the store. When no milestone is
+// left focus mode ends.
+func (m *focusModel) load() error {
+	goal, err := m.app.Store.Goal(m.goalID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		return err
+	}
+	m.goal = goal
+
+	var milestone *models.Task
+	if m.pinnedID != 0 {
+		milestone, err = m.app.Store.Task(m.pinnedID)
+		if errors.Is(err, store.ErrNotFound) {
+			m.pinnedID = 0
+		} else if err != nil {
+			return err
+		}
+	}
+	if m.pinnedID == 0 {
+		milestone, err = m.app.Store.NextMilestone(m.goalID)
+		if errors.Is(err, store.ErrNotFound) {
+			m.milestone = nil
+			m.farewell = func() { ui.RenderSubtitle("No milestones left to work on.") }
+			if goal.Status == "COMPLETED" {
+				m.farewell = func() { ui.RenderSuccess("All milestones completed! Goal marked as COMPLETED.") }
+			}
+			return nil
+		} else if err != nil {
 			return err
 		}
+	}
+	m.milestone = milestone
+
+	tasks, err := m.app.Store.Tasks(m.goalID)
+	if err != nil {
+		return err
+	}
+	m.tree.setTasks(tasks)
+
+	m.subtasks = m.tree.below(milestone.ID)
+	// Time budgets: estimates of the leaf tasks still to do
+	if m.milestoneMins, err = m.app.Store.RemainingMins(milestone.ID); err != nil {
+		return err
+	}
+	if m.goalMins, err = m.app.Store.GoalRemainingMins(m.goalID); err != nil {
+		return err
+	}
+	// Actual time next to the estimates
+	if m.spent, err = m.app.Store.TimeSpent(m.goalID); err != nil {
+		return err
+	}
+	if m.pomodoros, err = m.app.Store.Pomodoros(m.goalID); err != nil {
+		return err
+	}
+
+	m.session, err = m.app.Store.OpenSession()
+	if errors.Is(err, store.ErrNotFound) {
+		m.session = nil
+	} else if err != nil {
 		return err
 	}
+	// The interval follows the task being worked on, and no longer counts
+	// for one that was checked off, skipped or split
+	if m.session != nil {
+		m.timer.Work(m.session.TaskID, m.now)
+	} else {
+		m.timer.Unlink()
+	}
+
+	m.cursor = min(m.cursor, max(len(m.subtasks)-1, 0))
+	return nil
+}
 
-	// Header Section
-	fmt.Println(ui.BoxStyle.Render(fmt.Sprintf("[ %s ]", goalName)))
-	// ui.RenderStatus("STATUS:", goalStatus)
-	fmt.Println()
+func (m focusModel) Init() tea.Cmd {
+	return tick()
+}
 
-	// Find the first PENDING or IN_PROGRESS high-level task
-	var hlTask models.Task
-	err = a.DB.QueryRow(`
-		SELECT id, description, status
-		FROM tasks
-		WHERE goal_id = ? AND parent_task_id IS NULL AND status IN ('PENDING', 'IN_PROGRESS')
-		ORDER BY id ASC LIMIT 1`, goalID).Scan(&hlTask.ID, &hlTask.Description, &hlTask.Status)
+// reload refreshes after a change, quitting when focus mode is over.
+func (m focusModel) reload() (tea.Model, tea.Cmd) {
+	if err := m.load(); err != nil {
+		m.err = err
+		return m, tea.Quit
+	}
+	if m.milestone == nil {
+		return m, tea.Quit
+	}
+	return m, nil
+}
 
-	if err == sql.ErrNoRows {
-		// Mark goal as COMPLETED
-		_, err := a.DB.Exec("UPDATE goals SET status = 'COMPLETED' WHERE id = ?", goalID)
-		if err != nil {
-			return err
+func (m focusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		return m, nil
+
+	case tickMsg:
+		m.now = time.Time(msg)
+		if done, ok := m.timer.Advance(m.now); ok && done.taskID != 0 {
+			if err := m.app.Store.RecordPomodoro(done.taskID, done.started, done.end); err != nil {
+				m.status = fmt.Sprintf("Failed to record the pomodoro: %v", err)
+				return m, tick()
+			}
+			// Show the new count
+			if m.pomodoros, _ = m.app.Store.Pomodoros(m.goalID); m.pomodoros == nil {
+				m.pomodoros = map[int64]int{}
+			}
 		}
-		ui.RenderSuccess("All milestones completed! Goal marked as COMPLETED.")
-		return nil
+		return m, tick()
+
+	case plannerMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("No planner to break '%s' down: %v", msg.task.Description, msg.err)
+			return m, nil
+		}
+		m.planner = msg.planner
+		return m.split(msg.task)
+
+	case splitMsg:
+		m.cancelSplit()
+		m.splitting, m.cancelSplit = nil, nil
+		if msg.err == nil {
+			var ids []int64
+			if ids, msg.err = m.app.Store.SplitTask(msg.task.ID, msg.resp); msg.err == nil {
+				m.tree.expanded[msg.task.ID] = true
+				return m.edited(ids[0], fmt.Sprintf("Broke '%s' down into %d subtasks.", msg.task.Description, len(ids)), nil)
+			}
+		}
+		m.status = fmt.Sprintf("Couldn't break '%s' down: %v", msg.task.Description, msg.err)
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.updateKey(msg)
+	}
+	return m, nil
+}
+
+// failed reports a change that didn't go through, e.g. because another
+// kairos process held the database. Nothing was written, so focus mode
+// carries on with what's there now and the error on the status line.
+func (m focusModel) failed(err error) (tea.Model, tea.Cmd) {
+	next, cmd := m.reload()
+	fm := next.(focusModel)
+	fm.status = fmt.Sprintf("Nothing changed: %v", err)
+	return fm, cmd
+}
+
+// changed reloads after a subtask change, moving on when it completed the
+// milestone.
+func (m focusModel) changed(milestoneDone bool, err error) (tea.Model, tea.Cmd) {
+	if errors.Is(err, store.ErrProofRequired) {
+		m.status = "This goal requires proof of work to check a task off."
+		return m, nil
 	} else if err != nil {
-		return err
+		return m.failed(err)
+	}
+	if milestoneDone {
+		m.status = fmt.Sprintf("Milestone '%s' completed! Moving to next...", m.milestone.Description)
+		m.pinnedID = 0
+		m.cursor = 0
 	}
+	return m.reload()
+}
 
-	ui.RenderSubtitle("CURRENT TASK: " + hlTask.Description)
-	fmt.Println()
+func (m focusModel) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	if m.asking != noQuestion {
+		return m.updateAnswer(msg)
+	}
+	if m.browsing {
+		return m.updateTree(msg)
+	}
 
-	// Get subtasks for this HL task
-	rows, err := a.DB.Query(`
-		SELECT id, description, status
-		FROM tasks
-		WHERE parent_task_id = ?
-		ORDER BY id ASC`, hlTask.ID)
-	if err != nil {
-		return err
+	switch {
+	case key.Matches(msg, focusKeys.Quit):
+		m.farewell = func() { fmt.Println("Keep grinding 💪") }
+		return m, tea.Quit
+
+	case key.Matches(msg, focusKeys.Chill):
+		m.farewell = func() { ui.RenderSubtitle("Take a break. Run 'kairos chill'.") }
+		return m, tea.Quit
+
+	case key.Matches(msg, focusKeys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+
+	case key.Matches(msg, focusKeys.Tree):
+		m.browsing = true
+		m.tree.expanded[m.milestone.ID] = true
+		m.tree.focus(m.milestone.ID)
+		if len(m.subtasks) > 0 {
+			m.tree.focus(m.subtasks[m.cursor].ID)
+		}
+
+	case key.Matches(msg, focusKeys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case key.Matches(msg, focusKeys.Down):
+		if m.cursor < len(m.subtasks)-1 {
+			m.cursor++
+		}
+
+	case key.Matches(msg, focusKeys.Add):
+		return m.ask(askNewTask, m.milestone)
+	}
+
+	if len(m.subtasks) == 0 {
+		return m, nil
 	}
-	defer rows.Close()
+	sub := m.subtasks[m.cursor]
 
-	var subTasks []models.Task
-	var options []huh.Option[int64]
+	switch {
+	case len(m.tree.children[sub.ID]) > 0 && key.Matches(msg, focusKeys.Pick, focusKeys.Check, focusKeys.Skip, focusKeys.Unskip):
+		m.status = fmt.Sprintf("'%s' was split; work through its subtasks instead.", sub.Description)
+		return m, nil
 
-	// Add subtasks to options
-	for rows.Next() {
-		var t models.Task
-		if err := rows.Scan(&t.ID, &t.Description, &t.Status); err != nil {
-			continue
+	case key.Matches(msg, focusKeys.Pick), key.Matches(msg, focusKeys.Check):
+		// Picking a pending subtask starts working on it; picking it again
+		// while it's being worked on checks it off.
+		working := m.session != nil && m.session.TaskID == sub.ID
+		if key.Matches(msg, focusKeys.Pick) && sub.Status == "SKIPPED" {
+			m.status = "This subtask is skipped. Press u to unskip it."
+			return m, nil
+		}
+		if key.Matches(msg, focusKeys.Pick) && sub.Status != "DONE" && !working {
+			if err := m.app.Store.StartSession(sub.ID); err != nil {
+				return m.failed(err)
+			}
+			return m.reload()
+		}
+		if sub.Status == "DONE" {
+			return m.changed(m.app.Store.ToggleSubtask(sub.ID))
 		}
-		subTasks = append(subTasks, t)
+		// Checking it off asks for proof of work first
+		return m.ask(askProof, &sub)
 
-		label := fmt.Sprintf("[ ] %s", t.Description)
-		switch t.Status {
-		case "DONE":
-			label = fmt.Sprintf("[x] %s", t.Description)
-		case "SKIPPED":
-			label = fmt.Sprintf("[-] %s", t.Description)
+	case key.Matches(msg, focusKeys.Skip):
+		if sub.Status == "SKIPPED" {
+			return m, nil
 		}
+		return m.ask(askSkipReason, &sub)
+
+	case key.Matches(msg, focusKeys.Unskip):
+		return m.changed(false, m.app.Store.UnskipSubtask(sub.ID))
+
+	case key.Matches(msg, focusKeys.Defer):
+		if err := m.app.Store.DeferSubtask(sub.ID); err != nil {
+			return m.failed(err)
+		}
+		m.status = fmt.Sprintf("Deferred '%s' to the end.", sub.Description)
+		return m.reload()
+
+	case key.Matches(msg, focusKeys.Edit):
+		return m.ask(askRename, &sub)
+
+	case key.Matches(msg, focusKeys.Delete):
+		return m.ask(askDelete, &sub)
+
+	case key.Matches(msg, focusKeys.MoveUp):
+		return m.move(&sub, -1)
 
-		options = append(options, huh.NewOption(label, t.ID))
+	case key.Matches(msg, focusKeys.MoveDown):
+		return m.move(&sub, 1)
+
+	case key.Matches(msg, focusKeys.Split):
+		return m.split(&sub)
 	}
+	return m, nil
+}
 
-	// Footer Options
-	options = append(options, huh.NewOption("---", int64(-1)))
-	options = append(options, huh.NewOption("> I'm Exhausted (Switch Context)", int64(-99)))
+// move shifts a task by some places among its siblings.
+func (m focusModel) move(t *models.Task, by int) (tea.Model, tea.Cmd) {
+	siblings := m.tree.children[t.ParentTaskID.Int64]
+	to := max(slices.Index(siblings, t.ID)+by, 0)
+	return m.edited(t.ID, "", m.app.Store.MoveTask(t.ID, to))
+}
 
-	var selectedAction int64
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[int64]().
-				Title("").
-				Options(options...).
-				Value(&selectedAction).
-				// Height(16).
-				WithTheme(ui.HuhTheme),
-		),
-	)
+// plannerSetup builds the planner as a tea.ExecCommand, so a provider that
+// asks for credentials on first use gets the terminal to do it.
+type plannerSetup struct {
+	app     *app.App
+	planner ai.Planner
+}
 
-	if err := form.Run(); err != nil {
-		return err
+func (p *plannerSetup) Run() (err error) {
+	p.planner, err = p.app.Planner("")
+	return err
+}
+
+func (p *plannerSetup) SetStdin(io.Reader)  {}
+func (p *plannerSetup) SetStdout(io.Writer) {}
+func (p *plannerSetup) SetStderr(io.Writer) {}
+
+type plannerMsg struct {
+	planner ai.Planner
+	task    *models.Task
+	err     error
+}
+
+type splitMsg struct {
+	task *models.Task
+	resp *ai.Response
+	err  error
+}
+
+// split has the planner break a task down into subtasks in the background.
+func (m focusModel) split(t *models.Task) (tea.Model, tea.Cmd) {
+	switch {
+	case m.splitting != nil:
+		m.status = fmt.Sprintf("Still breaking '%s' down.", m.splitting.Description)
+		return m, nil
+	case t.Status == "DONE" || t.Status == "SKIPPED":
+		m.status = "Only unfinished tasks can be broken down."
+		return m, nil
+	case !t.ParentTaskID.Valid || len(m.tree.below(t.ID)) > 0:
+		m.status = fmt.Sprintf("'%s' has or needs subtasks of its own; break those down instead.", t.Description)
+		return m, nil
+	case m.planner == nil:
+		setup := &plannerSetup{app: m.app}
+		return m, tea.Exec(setup, func(err error) tea.Msg {
+			return plannerMsg{planner: setup.planner, task: t, err: err}
+		})
+	}
+
+	// The whole split, retries included, gets as long as every attempt may
+	// take.
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if cfg := m.app.Config; cfg.AITimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), cfg.AITimeout*time.Duration(cfg.AIMaxRetries+1))
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
 	}
+	m.splitting, m.cancelSplit = t, cancel
+	planner := m.planner
+	req := ai.SplitRequest{
+		Goal:          m.goal.Name,
+		Context:       m.goal.Context.String,
+		Parents:       m.tree.parents(t.ID),
+		Task:          t.Description,
+		EstimatedMins: int(t.EstimatedDurationMins.Int64),
+	}
+	return m, func() tea.Msg {
+		resp, err := planner.SplitTask(ctx, req)
+		return splitMsg{task: t, resp: resp, err: err}
+	}
+}
 
-	if selectedAction == -1 {
-		return nil // Separator selected, do nothing
+// edited reloads after a task was added, changed or moved, keeping the
+// cursor on it.
+func (m focusModel) edited(id int64, status string, err error) (tea.Model, tea.Cmd) {
+	if err != nil {
+		return m.failed(err)
+	}
+	m.status = status
+	next, cmd := m.reload()
+	fm := next.(focusModel)
+	if fm.browsing {
+		fm.tree.focus(id)
+	}
+	for i, t := range fm.subtasks {
+		if t.ID == id {
+			fm.cursor = i
+		}
 	}
+	return fm, cmd
+}
 
-	if selectedAction == -99 {
-		// Switch Context (Chill Mode)
-		ui.RenderSubtitle("Take a break. Run 'kairos chill'.")
-		return nil
+// ask puts a question about target on the input line.
+func (m focusModel) ask(q question, target *models.Task) (tea.Model, tea.Cmd) {
+	m.asking = q
+	m.target = target
+	m.input.SetValue("")
+	switch q {
+	case askSkipReason:
+		m.input.Placeholder = "reason"
+	case askProof:
+		m.input.Placeholder = "note, URL or file path"
+	case askNewTask:
+		m.input.Placeholder = "description"
+	case askRename:
+		m.input.SetValue(target.Description)
+		m.input.CursorEnd()
+	case askDelete:
+		return m, nil
 	}
+	return m, m.input.Focus()
+}
 
-	// Handle Subtask Selection (Toggle)
-	var subTask models.Task
-	for _, t := range subTasks {
-		if t.ID == selectedAction {
-			subTask = t
-			break
+func (m focusModel) updateAnswer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	sub := m.target
+
+	if m.asking == askDelete {
+		m.asking = noQuestion
+		if key := msg.String(); key != "y" && key != "Y" {
+			m.status = "Nothing was deleted."
+			return m, nil
 		}
+		return m.edited(0, fmt.Sprintf("Deleted '%s'. 'kairos undo' brings it back.", sub.Description), m.app.Store.DeleteTask(sub.ID))
 	}
 
-	if subTask.ID != 0 {
-		newStatus := "DONE"
-		if subTask.Status == "DONE" {
-			newStatus = "PENDING"
+	switch msg.String() {
+	case "esc":
+		m.asking = noQuestion
+		m.input.Blur()
+		return m, nil
+
+	case "ctrl+g":
+		if m.asking != askProof {
+			break
 		}
-		_, err = a.DB.Exec("UPDATE tasks SET status = ? WHERE id = ?", newStatus, selectedAction)
+		commit, err := git.HeadCommit(".")
 		if err != nil {
-			return err
+			m.status = fmt.Sprintf("No commit to attach: %v", err)
+			return m, nil
 		}
+		m.input.SetValue(commit.String())
+		m.input.CursorEnd()
+		return m, nil
 
-		// Check if all subtasks are DONE
-		var pendingCount int
-		err = a.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE parent_task_id = ? AND status != 'DONE'", hlTask.ID).Scan(&pendingCount)
-		if err != nil {
-			return err
+	case "enter":
+		answer := strings.TrimSpace(m.input.Value())
+		switch m.asking {
+		case askSkipReason:
+			m.asking = noQuestion
+			m.input.Blur()
+			return m.changed(m.app.Store.SkipSubtask(sub.ID, answer))
+
+		case askNewTask, askRename:
+			if answer == "" {
+				return m, nil
+			}
+			q := m.asking
+			m.asking = noQuestion
+			m.input.Blur()
+			if q == askRename {
+				return m.edited(sub.ID, "", m.app.Store.EditTask(sub.ID, answer, sql.NullInt64{}))
+			}
+			var id int64
+			var err error
+			if sub == nil {
+				id, err = m.app.Store.AddMilestone(m.goalID, answer, 0)
+			} else {
+				id, err = m.app.Store.AddSubtask(sub.ID, answer, 0)
+			}
+			return m.edited(id, fmt.Sprintf("Added '%s'.", answer), err)
+		}
+
+		proof := proofEntry(answer)
+		if proof == "" && m.goal.ProofRequired && !sub.ProofOfWork.Valid {
+			m.status = "This goal requires proof of work to check a task off."
+			return m, nil
+		}
+		m.asking = noQuestion
+		m.input.Blur()
+		return m.changed(m.app.Store.CompleteSubtask(sub.ID, proof))
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m focusModel) updateTree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r, ok := m.tree.current()
+
+	switch {
+	case key.Matches(msg, treeKeys.Quit):
+		m.farewell = func() { fmt.Println("Keep grinding 💪") }
+		return m, tea.Quit
+
+	case key.Matches(msg, treeKeys.Back):
+		m.browsing = false
+
+	case key.Matches(msg, treeKeys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+
+	case key.Matches(msg, treeKeys.Up):
+		if m.tree.cursor > 0 {
+			m.tree.cursor--
+		}
+
+	case key.Matches(msg, treeKeys.Down):
+		if m.tree.cursor < len(m.tree.rows())-1 {
+			m.tree.cursor++
+		}
+
+	case key.Matches(msg, treeKeys.AddMilestone):
+		return m.ask(askNewTask, nil)
+	}
+
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, treeKeys.Expand):
+		if len(m.tree.children[r.task.ID]) > 0 {
+			m.tree.expanded[r.task.ID] = true
+		}
+
+	case key.Matches(msg, treeKeys.Collapse):
+		// Collapse the row, or move up to its parent when there's nothing to
+		// collapse
+		if m.tree.expanded[r.task.ID] {
+			m.tree.expanded[r.task.ID] = false
+		} else if r.task.ParentTaskID.Valid {
+			m.tree.focus(r.task.ParentTaskID.Int64)
+		}
+
+	case key.Matches(msg, treeKeys.Toggle):
+		if len(m.tree.children[r.task.ID]) > 0 {
+			m.tree.expanded[r.task.ID] = !m.tree.expanded[r.task.ID]
 		}
 
-		if pendingCount == 0 {
-			// Mark HL task as DONE
-			_, err = a.DB.Exec("UPDATE tasks SET status = 'DONE' WHERE id = ?", hlTask.ID)
-			if err != nil {
-				return err
+	case key.Matches(msg, treeKeys.AddSubtask):
+		milestone := m.tree.tasks[m.tree.milestoneOf(r.task.ID)]
+		m.tree.expanded[milestone.ID] = true
+		return m.ask(askNewTask, milestone)
+
+	case key.Matches(msg, treeKeys.Edit):
+		return m.ask(askRename, r.task)
+
+	case key.Matches(msg, treeKeys.Delete):
+		return m.ask(askDelete, r.task)
+
+	case key.Matches(msg, treeKeys.MoveUp):
+		return m.move(r.task, -1)
+
+	case key.Matches(msg, treeKeys.MoveDown):
+		return m.move(r.task, 1)
+
+	case key.Matches(msg, treeKeys.Split):
+		return m.split(r.task)
+
+	case key.Matches(msg, treeKeys.Jump):
+		// Focus on the milestone of the row, with the cursor on the row
+		m.pinnedID = m.tree.milestoneOf(r.task.ID)
+		m.browsing = false
+		m.cursor = 0
+		next, cmd := m.reload()
+		fm := next.(focusModel)
+		for i, t := range fm.subtasks {
+			if t.ID == r.task.ID {
+				fm.cursor = i
 			}
-			ui.RenderSuccess("Milestone completed! Moving to next...")
-			// Optional: Sleep briefly to let user see the success message?
-			// time.Sleep(1 * time.Second)
-		} else {
-			// Ensure HL task is IN_PROGRESS
-			if hlTask.Status == "PENDING" {
-				_, err = a.DB.Exec("UPDATE tasks SET status = 'IN_PROGRESS' WHERE id = ?", hlTask.ID)
-				if err != nil {
-					return err
+		}
+		return fm, cmd
+	}
+	return m, nil
+}
+
+func (m focusModel) View() string {
+	if m.milestone == nil {
+		return ""
+	}
+	faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+	warn := lipgloss.NewStyle().Foreground(ui.AccentColor)
+
+	var workingID int64
+	if m.session != nil {
+		workingID = m.session.TaskID
+	}
+
+	header := []string{ui.BoxStyle.Render(fmt.Sprintf("[ %s ]", m.goal.Name)), ""}
+	var lines []string
+	var keys help.KeyMap
+	cursor := m.cursor
+
+	if m.browsing {
+		done, total := m.tree.progress(0)
+		header = append(header,
+			ui.SubtitleStyle.Render("GOAL TREE"),
+			fmt.Sprintf("%s %s", progressBar(done, total, 20), faint.Render(fmt.Sprintf("%d/%d tasks done", done, total))),
+			"")
+		lines = m.tree.lines(m.milestone.ID, workingID)
+		keys = treeKeys
+		cursor = m.tree.cursor
+	} else {
+		current := "CURRENT TASK: " + m.milestone.Description
+		if m.milestone.Status == "DONE" {
+			current += " (done)"
+		}
+		header = append(header, ui.SubtitleStyle.Render(current))
+		if m.milestone.NeedsPlanning {
+			header = append(header, statusLine("NEEDS PLANNING:", "run 'kairos plan resume' to generate its subtasks"))
+		}
+		if m.goalMins > 0 {
+			header = append(header,
+				statusLine("MILESTONE LEFT:", "~"+ui.FormatDuration(m.milestoneMins)),
+				statusLine("GOAL LEFT:", "~"+ui.FormatDuration(m.goalMins)))
+		}
+		if m.milestone.EstimatedDurationMins.Valid || m.spent[m.milestone.ID] > 0 {
+			header = append(header, statusLine("MILESTONE TIME:", timeLabel(m.milestone, m.spent[m.milestone.ID], 0)))
+		}
+		header = append(header, m.timer.View(m.now), "")
+
+		for i, t := range m.subtasks {
+			// Tasks that were split show their progress instead of a mark
+			text := strings.Repeat("    ", m.tree.depth(t.ID)-1)
+			if len(m.tree.children[t.ID]) > 0 {
+				done, total := m.tree.progress(t.ID)
+				text += fmt.Sprintf("▾ %s  %s %s", t.Description, progressBar(done, total, 10), faint.Render(fmt.Sprintf("%d/%d", done, total)))
+			} else {
+				mark := "[ ]"
+				switch {
+				case t.Status == "DONE":
+					mark = "[x]"
+				case t.Status == "SKIPPED":
+					mark = "[-]"
+				case t.ID == workingID:
+					mark = "[>]"
 				}
+				text += mark + " " + t.Description
+			}
+			if t.EstimatedDurationMins.Valid || m.spent[t.ID] > 0 || m.pomodoros[t.ID] > 0 {
+				text += faint.Render(" (" + timeLabel(&t, m.spent[t.ID], m.pomodoros[t.ID]) + ")")
+			}
+			if t.SkipReason.Valid {
+				text += faint.Render(" skipped: " + t.SkipReason.String)
+			}
+
+			if i == m.cursor {
+				lines = append(lines, ui.SelectedStyle.Render("> "+text))
+			} else {
+				lines = append(lines, ui.ItemStyle.Render("  "+text))
 			}
 		}
+		if len(lines) == 0 {
+			lines = append(lines, warn.Render("No subtasks yet."))
+		}
+		keys = focusKeys
+	}
+
+	footer := []string{""}
+	if m.splitting != nil {
+		footer = append(footer, faint.Render(fmt.Sprintf("Breaking '%s' down with the planner...", m.splitting.Description)))
+	}
+	if m.status != "" {
+		footer = append(footer, faint.Render(m.status))
+	}
+	switch m.asking {
+	case askSkipReason:
+		footer = append(footer,
+			fmt.Sprintf("Why skip '%s'? %s", m.target.Description, faint.Render("(optional; enter to skip, esc to cancel)")),
+			m.input.View())
+	case askProof:
+		hint := "(optional; ctrl+g for the last commit, enter to check it off, esc to cancel)"
+		if m.goal.ProofRequired {
+			hint = "(required by this goal; ctrl+g for the last commit, esc to cancel)"
+		}
+		footer = append(footer,
+			fmt.Sprintf("Proof of work for '%s'? %s", m.target.Description, faint.Render(hint)),
+			m.input.View())
+	case askNewTask:
+		prompt := fmt.Sprintf("New milestone for '%s'?", m.goal.Name)
+		if m.target != nil {
+			prompt = fmt.Sprintf("New subtask of '%s'?", m.target.Description)
+		}
+		footer = append(footer, prompt+" "+faint.Render("(enter to add, esc to cancel)"), m.input.View())
+	case askRename:
+		footer = append(footer,
+			fmt.Sprintf("Rename '%s'? %s", m.target.Description, faint.Render("(enter to save, esc to cancel)")),
+			m.input.View())
+	case askDelete:
+		what := m.target.Description
+		if len(m.tree.children[m.target.ID]) > 0 {
+			what += "' and everything under it"
+		} else {
+			what += "'"
+		}
+		footer = append(footer, fmt.Sprintf("Delete '%s? %s", what, faint.Render("(y/N)")))
+	default:
+		footer = append(footer, m.help.View(keys))
+	}
+
+	// Scroll so the cursor stays inside the window
+	top := strings.Join(header, "\n")
+	bottom := strings.Join(footer, "\n")
+	visible := len(lines)
+	if m.height > 0 {
+		visible = max(m.height-lipgloss.Height(top)-lipgloss.Height(bottom), 3)
+	}
+	offset := max(cursor-visible+1, 0)
+	end := min(offset+visible, len(lines))
+
+	return top + "\n" + strings.Join(lines[offset:end], "\n") + "\n" + bottom
+}
 
-		return RunFocusMode(a, goalID)
+// RunFocusMode shows the next milestone of a goal and its subtasks until the
+// user quits. Finishing the last subtask of a milestone moves on to the next
+// one, and finishing the last milestone completes the goal.
+func RunFocusMode(a *app.App, goalID int64) error {
+	m := focusModel{
+		app:    a,
+		goalID: goalID,
+		timer:  NewPomodoro(a.Config),
+		now:    time.Now(),
+		tree:   newGoalTree(nil),
+		input:  newAnswerInput(),
+		help:   help.New(),
+	}
+	if err := m.load(); err != nil {
+		return err
+	}
+	if m.milestone == nil {
+		m.farewell()
+		return nil
+	}
+
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+
+	fm := final.(focusModel)
+	if fm.cancelSplit != nil {
+		fm.cancelSplit()
+	}
+	if fm.err != nil {
+		return fm.err
+	}
+	if fm.farewell != nil {
+		fm.farewell()
 	}
 	return nil
 }
@@ -0,0 +1,346 @@
+package tui
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/app"
+	"github.com/yagnikpt/kairos/internal/config"
+	"github.com/yagnikpt/kairos/internal/database"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/store"
+)
+
+func newFocusModel(t *testing.T) focusModel {
+	t.Helper()
+	db, err := database.InitDB(database.MemoryPath)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	a := &app.App{Store: store.New(db), Config: &config.Config{PomodoroWork: 25 * time.Minute}}
+	id, err := a.Store.CreateGoalWithPlan(models.Goal{Name: "Learn Go"}, []ai.Milestone{
+		{PlanItem: ai.PlanItem{Title: "Basics"}, Subtasks: []ai.PlanItem{{Title: "Read"}, {Title: "Practice"}}},
+		{PlanItem: ai.PlanItem{Title: "Advanced"}, Subtasks: []ai.PlanItem{{Title: "Generics"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGoalWithPlan: %v", err)
+	}
+
+	m := focusModel{app: a, goalID: id, timer: NewPomodoro(a.Config), now: time.Now(), tree: newGoalTree(nil), input: newAnswerInput(), help: help.New()}
+	if err := m.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return m
+}
+
+// press sends a key and fails the test if the model gave up on an error.
+func press(t *testing.T, m focusModel, k string) (focusModel, tea.Cmd) {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	switch k {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "backspace":
+		msg = tea.KeyMsg{Type: tea.KeyBackspace}
+	}
+	next, cmd := m.Update(msg)
+	fm := next.(focusModel)
+	if fm.err != nil {
+		t.Fatalf("after %q: %v", k, fm.err)
+	}
+	return fm, cmd
+}
+
+// typeText types into the input line.
+func typeText(t *testing.T, m focusModel, text string) focusModel {
+	t.Helper()
+	for _, r := range text {
+		m, _ = press(t, m, string(r))
+	}
+	return m
+}
+
+func TestFocusAutoAdvance(t *testing.T) {
+	m := newFocusModel(t)
+	if m.milestone.Description != "Basics" {
+		t.Fatalf("milestone = %q, want Basics", m.milestone.Description)
+	}
+
+	// The first pick starts working, the second checks the subtask off
+	// after asking for proof of work
+	m, _ = press(t, m, "enter")
+	if m.session == nil || m.session.TaskID != m.subtasks[0].ID {
+		t.Fatalf("session = %+v, want one on %q", m.session, m.subtasks[0].Description)
+	}
+	m, _ = press(t, m, "enter")
+	if m.asking != askProof {
+		t.Fatal("checking off didn't ask for proof")
+	}
+	m = typeText(t, m, "read chapter 1")
+	m, _ = press(t, m, "enter")
+	if m.subtasks[0].Status != "DONE" || m.subtasks[0].ProofOfWork.String != "read chapter 1" || m.session != nil {
+		t.Fatalf("after second pick: %+v, session %+v", m.subtasks[0], m.session)
+	}
+
+	// Proof is optional
+	m, _ = press(t, m, "j")
+	m, _ = press(t, m, "x")
+	m, _ = press(t, m, "enter")
+	if m.milestone.Description != "Advanced" || m.cursor != 0 {
+		t.Fatalf("milestone = %q, cursor %d; want Advanced, 0", m.milestone.Description, m.cursor)
+	}
+	if m.status == "" {
+		t.Error("no message about the completed milestone")
+	}
+
+	m, _ = press(t, m, "x")
+	m, cmd := press(t, m, "enter")
+	if cmd == nil || m.milestone != nil || m.farewell == nil {
+		t.Fatal("finishing the last milestone didn't end focus mode")
+	}
+	goal, err := m.app.Store.Goal(m.goalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goal.Status != "COMPLETED" {
+		t.Errorf("goal status = %s, want COMPLETED", goal.Status)
+	}
+}
+
+func TestFocusPomodoroUnlinkedWhenDone(t *testing.T) {
+	m := newFocusModel(t)
+	m, _ = press(t, m, "enter")
+	m, _ = press(t, m, "x")
+	m, _ = press(t, m, "enter")
+	if m.session != nil {
+		t.Fatal("checking off didn't stop the session")
+	}
+
+	// The interval ends after the task was checked off, so it counts for
+	// nothing
+	next, _ := m.Update(tickMsg(m.now.Add(25 * time.Minute)))
+	m = next.(focusModel)
+	if len(m.pomodoros) != 0 {
+		t.Errorf("pomodoros = %v, want none for a finished task", m.pomodoros)
+	}
+}
+
+func TestFocusStoreErrorKeepsRunning(t *testing.T) {
+	m := newFocusModel(t)
+	read := m.subtasks[0]
+
+	// Another kairos process deletes the task while it's on screen
+	if err := m.app.Store.DeleteTask(read.ID); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = press(t, m, "x")
+	m, cmd := press(t, m, "enter")
+	if cmd != nil {
+		t.Fatal("a failed change ended focus mode")
+	}
+	if !strings.Contains(m.status, "Nothing changed") {
+		t.Errorf("status = %q, want the error", m.status)
+	}
+	if len(m.subtasks) != 1 || m.subtasks[0].Description != "Practice" {
+		t.Errorf("subtasks = %+v, want the view reloaded without Read", m.subtasks)
+	}
+}
+
+func TestFocusJumpToMilestone(t *testing.T) {
+	m := newFocusModel(t)
+
+	// The tree opens on the subtask under the cursor
+	m, _ = press(t, m, "t")
+	r, _ := m.tree.current()
+	if !m.browsing || r.task.Description != "Read" {
+		t.Fatalf("tree cursor on %q, want Read", r.task.Description)
+	}
+	if done, total := m.tree.progress(0); done != 0 || total != 3 {
+		t.Errorf("goal progress = %d/%d, want 0/3", done, total)
+	}
+
+	m, _ = press(t, m, "j")
+	m, _ = press(t, m, "j")
+	m, _ = press(t, m, "enter")
+	if m.browsing || m.milestone.Description != "Advanced" {
+		t.Fatalf("focus on %q after jumping, want Advanced", m.milestone.Description)
+	}
+
+	// Finishing the picked milestone goes back to the first unfinished one
+	m, _ = press(t, m, "x")
+	m, _ = press(t, m, "enter")
+	if m.milestone.Description != "Basics" || m.pinnedID != 0 {
+		t.Errorf("focus on %q (pinned %d), want Basics", m.milestone.Description, m.pinnedID)
+	}
+	if done, total := m.tree.progress(0); done != 1 || total != 3 {
+		t.Errorf("goal progress = %d/%d, want 1/3", done, total)
+	}
+}
+
+func TestFocusSkipAndDefer(t *testing.T) {
+	m := newFocusModel(t)
+
+	m, _ = press(t, m, "d")
+	if m.subtasks[0].Description != "Practice" || m.subtasks[1].Description != "Read" {
+		t.Fatalf("order after deferring = %q, %q", m.subtasks[0].Description, m.subtasks[1].Description)
+	}
+
+	m, _ = press(t, m, "s")
+	if m.asking != askSkipReason {
+		t.Fatal("skip didn't ask for a reason")
+	}
+	m = typeText(t, m, "not needed")
+	m, _ = press(t, m, "enter")
+	if m.asking != noQuestion || m.subtasks[0].Status != "SKIPPED" || m.subtasks[0].SkipReason.String != "not needed" {
+		t.Fatalf("after skipping: %+v", m.subtasks[0])
+	}
+
+	// Skipping the last open subtask completes the milestone
+	m, _ = press(t, m, "j")
+	m, _ = press(t, m, "s")
+	m, _ = press(t, m, "enter")
+	if m.milestone.Description != "Advanced" {
+		t.Errorf("milestone = %q, want Advanced", m.milestone.Description)
+	}
+}
+
+func TestFocusProofRequired(t *testing.T) {
+	m := newFocusModel(t)
+	if err := m.app.Store.SetProofRequired(m.goalID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without proof the prompt stays open
+	m, _ = press(t, m, "x")
+	m, _ = press(t, m, "enter")
+	if m.asking != askProof || m.status == "" || m.subtasks[0].Status == "DONE" {
+		t.Fatalf("checked off without proof: asking %d, status %q", m.asking, m.status)
+	}
+
+	m = typeText(t, m, "https://go.dev/tour")
+	m, _ = press(t, m, "enter")
+	if m.asking != noQuestion || m.subtasks[0].Status != "DONE" {
+		t.Errorf("not checked off with proof: %+v", m.subtasks[0])
+	}
+}
+
+func TestFocusEditTasks(t *testing.T) {
+	m := newFocusModel(t)
+
+	m, _ = press(t, m, "a")
+	m = typeText(t, m, "Quiz")
+	m, _ = press(t, m, "enter")
+	if len(m.subtasks) != 3 || m.subtasks[2].Description != "Quiz" || m.cursor != 2 {
+		t.Fatalf("after adding: cursor %d on %d subtasks", m.cursor, len(m.subtasks))
+	}
+
+	m, _ = press(t, m, "K")
+	m, _ = press(t, m, "e")
+	m.input.SetValue("Pop quiz")
+	m, _ = press(t, m, "enter")
+	if m.cursor != 1 || m.subtasks[1].Description != "Pop quiz" {
+		t.Fatalf("after moving and renaming: cursor %d on %q", m.cursor, m.subtasks[1].Description)
+	}
+
+	// Deleting asks first
+	m, _ = press(t, m, "backspace")
+	m, _ = press(t, m, "n")
+	if len(m.subtasks) != 3 {
+		t.Fatal("deleted without confirmation")
+	}
+	m, _ = press(t, m, "backspace")
+	m, _ = press(t, m, "y")
+	if len(m.subtasks) != 2 || m.subtasks[1].Description != "Practice" {
+		t.Errorf("after deleting: %+v", m.subtasks)
+	}
+
+	// New milestones are added from the tree
+	m, _ = press(t, m, "t")
+	m, _ = press(t, m, "a")
+	m = typeText(t, m, "Projects")
+	m, _ = press(t, m, "enter")
+	if r, _ := m.tree.current(); !m.browsing || r.task.Description != "Projects" || r.depth != 0 {
+		t.Errorf("tree cursor on %+v after adding a milestone", r)
+	}
+}
+
+// splitPlanner is a planner that can only split tasks, always in two.
+type splitPlanner struct{ ai.Planner }
+
+func (splitPlanner) SplitTask(ctx context.Context, req ai.SplitRequest) (*ai.Response, error) {
+	items := []ai.PlanItem{{Title: req.Task + " 1", EstimatedDurationMins: 10}, {Title: req.Task + " 2", EstimatedDurationMins: 10}}
+	return &ai.Response{Items: items, Raw: "split " + req.Task}, nil
+}
+
+// blockingPlanner splits only once its context is done.
+type blockingPlanner struct{ ai.Planner }
+
+func (blockingPlanner) SplitTask(ctx context.Context, req ai.SplitRequest) (*ai.Response, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestFocusExplodeCancelled(t *testing.T) {
+	m := newFocusModel(t)
+	m.planner = blockingPlanner{}
+	m.app.Config.AITimeout = time.Millisecond
+
+	m, cmd := press(t, m, "X")
+	if m.cancelSplit == nil {
+		t.Fatal("split has no way to be cancelled")
+	}
+	// Runs into its deadline instead of hanging
+	next, _ := m.Update(cmd())
+	m = next.(focusModel)
+	if m.splitting != nil || !strings.Contains(m.status, "deadline") {
+		t.Errorf("status = %q, want the split to give up", m.status)
+	}
+}
+
+func TestFocusExplode(t *testing.T) {
+	m := newFocusModel(t)
+	m.planner = splitPlanner{}
+
+	m, cmd := press(t, m, "X")
+	if m.splitting == nil || cmd == nil {
+		t.Fatal("exploding didn't ask the planner")
+	}
+	next, _ := m.Update(cmd())
+	m = next.(focusModel)
+	if m.splitting != nil || m.err != nil {
+		t.Fatalf("still splitting after the answer: %v", m.err)
+	}
+	var got []string
+	for _, sub := range m.subtasks {
+		got = append(got, sub.Description)
+	}
+	if want := []string{"Read", "Read 1", "Read 2", "Practice"}; !reflect.DeepEqual(got, want) || m.cursor != 1 {
+		t.Fatalf("subtasks = %v with the cursor on %d, want %v on 1", got, m.cursor, want)
+	}
+
+	// The split task is finished through its subtasks
+	m, _ = press(t, m, "k")
+	m, _ = press(t, m, "x")
+	if m.asking != noQuestion || m.status == "" {
+		t.Error("checking off a split task wasn't refused")
+	}
+	for range 2 {
+		m, _ = press(t, m, "j")
+		m, _ = press(t, m, "x")
+		m, _ = press(t, m, "enter")
+	}
+	if m.subtasks[0].Status != "DONE" {
+		t.Errorf("split task = %s after finishing its subtasks, want DONE", m.subtasks[0].Status)
+	}
+}
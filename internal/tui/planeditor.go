@@ -0,0 +1,518 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+type editorKeyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	MoveUp       key.Binding
+	MoveDown     key.Binding
+	Toggle       key.Binding
+	Edit         key.Binding
+	AddMilestone key.Binding
+	AddSubtask   key.Binding
+	Delete       key.Binding
+	Regenerate   key.Binding
+	Save         key.Binding
+	Quit         key.Binding
+	Help         key.Binding
+}
+
+func (k editorKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Toggle, k.Edit, k.Delete, k.Regenerate, k.Save, k.Quit, k.Help}
+}
+
+func (k editorKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.MoveUp, k.MoveDown},
+		{k.Toggle, k.Edit, k.AddMilestone, k.AddSubtask},
+		{k.Delete, k.Regenerate, k.Save, k.Quit},
+	}
+}
+
+var editorKeys = editorKeyMap{
+	Up:           key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:         key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	MoveUp:       key.NewBinding(key.WithKeys("K", "shift+up"), key.WithHelp("K", "move up")),
+	MoveDown:     key.NewBinding(key.WithKeys("J", "shift+down"), key.WithHelp("J", "move down")),
+	Toggle:       key.NewBinding(key.WithKeys(" ", "tab"), key.WithHelp("space", "expand")),
+	Edit:         key.NewBinding(key.WithKeys("e", "enter"), key.WithHelp("e", "rename")),
+	AddMilestone: key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "add milestone")),
+	AddSubtask:   key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "add subtask")),
+	Delete:       key.NewBinding(key.WithKeys("d", "x"), key.WithHelp("d", "delete")),
+	Regenerate:   key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "regenerate")),
+	Save:         key.NewBinding(key.WithKeys("ctrl+s", "w"), key.WithHelp("w", "save")),
+	Quit:         key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "cancel")),
+	Help:         key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+}
+
+type editorMilestone struct {
+	ai.Milestone
+	id           int // stable across reorders, used to route regenerate results
+	expanded     bool
+	regenerating bool
+}
+
+// editorRow addresses one visible line: a milestone (sub == -1) or one of
+// its subtasks.
+type editorRow struct {
+	m, sub int
+}
+
+type regeneratedMsg struct {
+	id   int
+	resp *ai.Response
+	err  error
+}
+
+type planEditorModel struct {
+	ctx     context.Context
+	planner ai.Planner
+	goal    string
+
+	milestones []editorMilestone
+	nextID     int
+	cursor     int
+	height     int
+
+	editing    bool
+	input      textinput.Model
+	confirming bool // asking whether to discard the plan
+	status     string
+
+	help  help.Model
+	saved bool
+}
+
+func (m planEditorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m planEditorModel) rows() []editorRow {
+	var rows []editorRow
+	for i, ms := range m.milestones {
+		rows = append(rows, editorRow{m: i, sub: -1})
+		if ms.expanded {
+			for j := range ms.Subtasks {
+				rows = append(rows, editorRow{m: i, sub: j})
+			}
+		}
+	}
+	return rows
+}
+
+func (m planEditorModel) current() (editorRow, bool) {
+	rows := m.rows()
+	if m.cursor < 0 || m.cursor >= len(rows) {
+		return editorRow{}, false
+	}
+	return rows[m.cursor], true
+}
+
+func (m *planEditorModel) focus(target editorRow) {
+	for i, r := range m.rows() {
+		if r == target {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *planEditorModel) clampCursor() {
+	n := len(m.rows())
+	if m.cursor >= n {
+		m.cursor = n - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *planEditorModel) title(r editorRow) *string {
+	if r.sub < 0 {
+		return &m.milestones[r.m].Title
+	}
+	return &m.milestones[r.m].Subtasks[r.sub].Title
+}
+
+func (m *planEditorModel) startEditing(r editorRow) tea.Cmd {
+	m.focus(r)
+	m.editing = true
+	m.input.SetValue(*m.title(r))
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m planEditorModel) regenerate(id int, title string) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := m.planner.GenerateSubTasks(m.ctx, title)
+		return regeneratedMsg{id: id, resp: resp, err: err}
+	}
+}
+
+func (m planEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.help.Width = msg.Width
+		return m, nil
+
+	case regeneratedMsg:
+		for i := range m.milestones {
+			if m.milestones[i].id != msg.id {
+				continue
+			}
+			ms := &m.milestones[i]
+			ms.regenerating = false
+			if msg.err != nil {
+				m.status = fmt.Sprintf("Failed to regenerate '%s': %v", ms.Title, msg.err)
+				ms.PlanningError = msg.err.Error()
+				break
+			}
+			ms.Subtasks, ms.RawResponse = msg.resp.Items, msg.resp.Raw
+			ms.NeedsPlanning, ms.PlanningError = false, ""
+			ms.expanded = true
+			m.status = fmt.Sprintf("Regenerated '%s'.", ms.Title)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		if m.confirming {
+			switch msg.String() {
+			case "y", "Y":
+				return m, tea.Quit
+			default:
+				m.confirming = false
+				m.status = ""
+			}
+			return m, nil
+		}
+		return m.updateBrowsing(msg)
+	}
+	return m, nil
+}
+
+func (m planEditorModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r, _ := m.current()
+	switch msg.String() {
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		m.editing = false
+		m.input.Blur()
+		if value == "" {
+			m.remove(r)
+			return m, nil
+		}
+		*m.title(r) = value
+		return m, nil
+	case "esc":
+		m.editing = false
+		m.input.Blur()
+		// Drop items that were just added and never named
+		if *m.title(r) == "" {
+			m.remove(r)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *planEditorModel) remove(r editorRow) {
+	if r.sub < 0 {
+		m.milestones = append(m.milestones[:r.m], m.milestones[r.m+1:]...)
+	} else {
+		ms := &m.milestones[r.m]
+		ms.Subtasks = append(ms.Subtasks[:r.sub], ms.Subtasks[r.sub+1:]...)
+	}
+	m.clampCursor()
+}
+
+func (m planEditorModel) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	r, ok := m.current()
+
+	switch {
+	case key.Matches(msg, editorKeys.Quit):
+		m.confirming = true
+		m.status = "Discard this plan? (y/N)"
+		return m, nil
+
+	case key.Matches(msg, editorKeys.Save):
+		if len(m.milestones) == 0 {
+			m.status = "A plan needs at least one milestone."
+			return m, nil
+		}
+		for _, ms := range m.milestones {
+			if ms.regenerating {
+				m.status = "Wait for regeneration to finish before saving."
+				return m, nil
+			}
+		}
+		m.saved = true
+		return m, tea.Quit
+
+	case key.Matches(msg, editorKeys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+		return m, nil
+
+	case key.Matches(msg, editorKeys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, editorKeys.Down):
+		if m.cursor < len(m.rows())-1 {
+			m.cursor++
+		}
+		return m, nil
+
+	case key.Matches(msg, editorKeys.AddMilestone):
+		at := len(m.milestones)
+		if ok {
+			at = r.m + 1
+		}
+		ms := editorMilestone{id: m.nextID}
+		m.nextID++
+		m.milestones = append(m.milestones[:at], append([]editorMilestone{ms}, m.milestones[at:]...)...)
+		return m, m.startEditing(editorRow{m: at, sub: -1})
+	}
+
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, editorKeys.MoveUp), key.Matches(msg, editorKeys.MoveDown):
+		delta := -1
+		if key.Matches(msg, editorKeys.MoveDown) {
+			delta = 1
+		}
+		if r.sub < 0 {
+			to := r.m + delta
+			if to < 0 || to >= len(m.milestones) {
+				return m, nil
+			}
+			m.milestones[r.m], m.milestones[to] = m.milestones[to], m.milestones[r.m]
+			m.focus(editorRow{m: to, sub: -1})
+		} else {
+			subs := m.milestones[r.m].Subtasks
+			to := r.sub + delta
+			if to < 0 || to >= len(subs) {
+				return m, nil
+			}
+			subs[r.sub], subs[to] = subs[to], subs[r.sub]
+			m.focus(editorRow{m: r.m, sub: to})
+		}
+
+	case key.Matches(msg, editorKeys.Toggle):
+		ms := &m.milestones[r.m]
+		ms.expanded = !ms.expanded
+		m.focus(editorRow{m: r.m, sub: -1})
+
+	case key.Matches(msg, editorKeys.Edit):
+		return m, m.startEditing(r)
+
+	case key.Matches(msg, editorKeys.AddSubtask):
+		ms := &m.milestones[r.m]
+		at := len(ms.Subtasks)
+		if r.sub >= 0 {
+			at = r.sub + 1
+		}
+		ms.Subtasks = append(ms.Subtasks[:at], append([]ai.PlanItem{{}}, ms.Subtasks[at:]...)...)
+		ms.expanded = true
+		return m, m.startEditing(editorRow{m: r.m, sub: at})
+
+	case key.Matches(msg, editorKeys.Delete):
+		m.remove(r)
+
+	case key.Matches(msg, editorKeys.Regenerate):
+		ms := &m.milestones[r.m]
+		if ms.regenerating {
+			return m, nil
+		}
+		ms.regenerating = true
+		m.focus(editorRow{m: r.m, sub: -1})
+		return m, m.regenerate(ms.id, ms.Title)
+	}
+	return m, nil
+}
+
+func (m planEditorModel) View() string {
+	var lines []string
+	faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+	warn := lipgloss.NewStyle().Foreground(ui.AccentColor)
+
+	rows := m.rows()
+	for i, r := range rows {
+		ms := m.milestones[r.m]
+		selected := i == m.cursor
+
+		var text string
+		if r.sub < 0 {
+			arrow := "▸"
+			if ms.expanded {
+				arrow = "▾"
+			}
+			text = fmt.Sprintf("%s %s", arrow, ms.Title)
+			if selected && m.editing {
+				text = fmt.Sprintf("%s %s", arrow, m.input.View())
+			}
+			switch {
+			case ms.regenerating:
+				text += faint.Render("  regenerating...")
+			case len(ms.Subtasks) == 0:
+				text += warn.Render("  needs planning")
+			default:
+				text += faint.Render(fmt.Sprintf("  %d subtasks", len(ms.Subtasks)))
+			}
+		} else {
+			sub := ms.Subtasks[r.sub]
+			text = "    • " + sub.Title
+			if selected && m.editing {
+				text = "    • " + m.input.View()
+			} else if sub.EstimatedDurationMins > 0 {
+				text += faint.Render(" (" + ui.FormatDuration(sub.EstimatedDurationMins) + ")")
+			}
+		}
+
+		if selected {
+			lines = append(lines, ui.SelectedStyle.Render(text))
+		} else {
+			lines = append(lines, ui.ItemStyle.Render(text))
+		}
+	}
+	if len(rows) == 0 {
+		lines = append(lines, faint.Render("No milestones. Press a to add one."))
+	}
+
+	details := strings.Join(m.details(), "\n")
+
+	// Scroll so the cursor stays inside the window
+	visible := len(lines)
+	if m.height > 0 {
+		visible = max(m.height-12-lipgloss.Height(details), 3)
+	}
+	offset := max(m.cursor-visible+1, 0)
+	end := min(offset+visible, len(lines))
+
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render("Review plan: " + m.goal))
+	b.WriteString("\n")
+	b.WriteString(strings.Join(lines[offset:end], "\n"))
+	b.WriteString("\n\n")
+	if details != "" {
+		b.WriteString(details)
+		b.WriteString("\n\n")
+	}
+	if m.status != "" {
+		b.WriteString(faint.Render(m.status))
+		b.WriteString("\n")
+	}
+	b.WriteString(m.help.View(editorKeys))
+
+	return ui.BoxStyle.Render(b.String())
+}
+
+// details describes the item under the cursor: its estimate, why it's in
+// the plan and, for a milestone without subtasks, why it needs planning.
+func (m planEditorModel) details() []string {
+	r, ok := m.current()
+	if !ok || m.editing {
+		return nil
+	}
+	faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+	warn := lipgloss.NewStyle().Foreground(ui.AccentColor)
+	// Wrap long rationales and errors inside the box
+	if m.help.Width > 10 {
+		faint, warn = faint.Width(m.help.Width-8), warn.Width(m.help.Width-8)
+	}
+
+	ms := m.milestones[r.m]
+	item := ms.PlanItem
+	if r.sub >= 0 {
+		item = ms.Subtasks[r.sub]
+	}
+
+	var lines []string
+	if item.EstimatedDurationMins > 0 {
+		lines = append(lines, faint.Render("Estimate: "+ui.FormatDuration(item.EstimatedDurationMins)))
+	}
+	if item.Rationale != "" {
+		lines = append(lines, faint.Render("Why: "+item.Rationale))
+	}
+	if r.sub < 0 && len(ms.Subtasks) == 0 && !ms.regenerating {
+		reason := "it has no subtasks yet; press r to generate them"
+		if ms.PlanningError != "" {
+			reason = "generating its subtasks failed: " + ms.PlanningError
+		}
+		lines = append(lines, warn.Render("Needs planning: "+reason))
+	}
+	return lines
+}
+
+// RunPlanEditor lets the user reorder, rename, delete and add milestones and
+// subtasks, and regenerate a single milestone's subtasks, before the plan is
+// saved. It returns false if the user discarded the plan.
+func RunPlanEditor(ctx context.Context, planner ai.Planner, goal string, milestones []ai.Milestone) ([]ai.Milestone, bool, error) {
+	input := textinput.New()
+	input.Prompt = ""
+	input.CharLimit = 200
+
+	m := planEditorModel{
+		ctx:     ctx,
+		planner: planner,
+		goal:    goal,
+		input:   input,
+		help:    help.New(),
+	}
+	for _, ms := range milestones {
+		m.milestones = append(m.milestones, editorMilestone{Milestone: ms, id: m.nextID})
+		m.nextID++
+	}
+
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, false, err
+	}
+
+	fm := final.(planEditorModel)
+	if !fm.saved {
+		return nil, false, nil
+	}
+
+	var plan []ai.Milestone
+	for _, ms := range fm.milestones {
+		if ms.Title == "" {
+			continue
+		}
+		var subtasks []ai.PlanItem
+		for _, sub := range ms.Subtasks {
+			if sub.Title != "" {
+				subtasks = append(subtasks, sub)
+			}
+		}
+		ms.Subtasks = subtasks
+		ms.NeedsPlanning = len(subtasks) == 0
+		plan = append(plan, ms.Milestone)
+	}
+	return plan, true, nil
+}
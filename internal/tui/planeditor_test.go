@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yagnikpt/kairos/internal/ai"
+)
+
+func TestPlanEditorDetails(t *testing.T) {
+	m := planEditorModel{milestones: []editorMilestone{
+		{Milestone: ai.Milestone{
+			PlanItem: ai.PlanItem{Title: "Basics", EstimatedDurationMins: 90, Rationale: "Everything builds on it"},
+			Subtasks: []ai.PlanItem{{Title: "Read", EstimatedDurationMins: 20}},
+		}},
+		{Milestone: ai.Milestone{
+			PlanItem:      ai.PlanItem{Title: "Advanced"},
+			NeedsPlanning: true,
+			PlanningError: "rate limited",
+		}},
+	}}
+
+	got := strings.Join(m.details(), "\n")
+	for _, want := range []string{"Estimate: 1h30m", "Everything builds on it"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("details of Basics = %q, want %q in them", got, want)
+		}
+	}
+
+	m.cursor = 1
+	if got := strings.Join(m.details(), "\n"); !strings.Contains(got, "Needs planning: generating its subtasks failed: rate limited") {
+		t.Errorf("details of Advanced = %q, want why it needs planning", got)
+	}
+}
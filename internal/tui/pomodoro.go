@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yagnikpt/kairos/internal/config"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+type pomodoroPhase int
+
+const (
+	phaseIdle pomodoroPhase = iota // Waiting for a task to be started
+	phaseWork
+	phaseShortBreak
+	phaseLongBreak
+)
+
+// Pomodoro is the focus mode timer: work intervals separated by short
+// breaks, with a long break after every few intervals.
+type Pomodoro struct {
+	work, shortBreak, longBreak time.Duration
+	longBreakEvery              int
+
+	phase   pomodoroPhase
+	started time.Time
+	done    int   // Work intervals finished
+	taskID  int64 // Task worked on during the current interval
+}
+
+// NewPomodoro sets the timer up from the config. Lengths of zero or less
+// would end every phase at once, so the defaults stand in for them.
+func NewPomodoro(cfg *config.Config) *Pomodoro {
+	return &Pomodoro{
+		work:           positive(cfg.PomodoroWork, config.DefaultPomodoroWork),
+		shortBreak:     positive(cfg.PomodoroShortBreak, config.DefaultPomodoroShortBreak),
+		longBreak:      positive(cfg.PomodoroLongBreak, config.DefaultPomodoroLongBreak),
+		longBreakEvery: max(cfg.PomodoroLongBreakEvery, 1),
+	}
+}
+
+func positive(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// Work links the timer to the task being worked on, starting a work
+// interval if the timer is idle.
+func (p *Pomodoro) Work(taskID int64, now time.Time) {
+	if p.phase == phaseIdle {
+		p.phase, p.started = phaseWork, now
+	}
+	if p.phase == phaseWork {
+		p.taskID = taskID
+	}
+}
+
+// Unlink stops crediting the current interval to any task, e.g. once its
+// task is finished. The interval keeps running for whatever is worked on
+// next.
+func (p *Pomodoro) Unlink() {
+	p.taskID = 0
+}
+
+func (p *Pomodoro) length() time.Duration {
+	switch p.phase {
+	case phaseWork:
+		return p.work
+	case phaseShortBreak:
+		return p.shortBreak
+	case phaseLongBreak:
+		return p.longBreak
+	}
+	return 0
+}
+
+// Remaining is the time left in the current phase.
+func (p *Pomodoro) Remaining(now time.Time) time.Duration {
+	return max(p.length()-now.Sub(p.started), 0)
+}
+
+// interval is a finished work interval.
+type interval struct {
+	taskID       int64
+	started, end time.Time
+}
+
+// Advance moves on to the next phase once the current one is over. When a
+// work interval ends it's returned, so it can be recorded for the task it
+// was linked to; a finished break goes straight into the next interval.
+func (p *Pomodoro) Advance(now time.Time) (interval, bool) {
+	if p.phase == phaseIdle || p.Remaining(now) > 0 {
+		return interval{}, false
+	}
+
+	end := p.started.Add(p.length())
+	if p.phase != phaseWork {
+		p.phase, p.started = phaseWork, end
+		return interval{}, false
+	}
+
+	done := interval{taskID: p.taskID, started: p.started, end: end}
+	p.done++
+	p.phase, p.started = phaseShortBreak, end
+	if p.done%p.longBreakEvery == 0 {
+		p.phase = phaseLongBreak
+	}
+	return done, true
+}
+
+// View renders the timer as a status line.
+func (p *Pomodoro) View(now time.Time) string {
+	label := lipgloss.NewStyle().Foreground(ui.SubTextColor).Render("POMODORO:")
+	value := ui.StatusStyle
+
+	left := p.Remaining(now).Round(time.Second)
+	clock := fmt.Sprintf("%02d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+	switch p.phase {
+	case phaseWork:
+		return fmt.Sprintf("%s %s %s", label, value.Render("WORK "+clock), fmt.Sprintf("(%d done)", p.done))
+	case phaseShortBreak, phaseLongBreak:
+		name := "BREAK "
+		if p.phase == phaseLongBreak {
+			name = "LONG BREAK "
+		}
+		rest := lipgloss.NewStyle().Foreground(ui.SecondaryColor).Render("Step away, or try 'kairos chill'.")
+		return fmt.Sprintf("%s %s %s", label, value.Render(name+clock), rest)
+	}
+	return fmt.Sprintf("%s %s", label, value.Render("starts with your first task"))
+}
+
+type tickMsg time.Time
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
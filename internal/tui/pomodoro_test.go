@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yagnikpt/kairos/internal/config"
+)
+
+func TestPomodoroCycle(t *testing.T) {
+	p := NewPomodoro(&config.Config{
+		PomodoroWork:           25 * time.Minute,
+		PomodoroShortBreak:     5 * time.Minute,
+		PomodoroLongBreak:      15 * time.Minute,
+		PomodoroLongBreakEvery: 2,
+	})
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(mins int) time.Time { return start.Add(time.Duration(mins) * time.Minute) }
+
+	if _, ok := p.Advance(at(60)); ok || p.phase != phaseIdle {
+		t.Fatalf("idle timer advanced to phase %d", p.phase)
+	}
+
+	p.Work(7, start)
+	if got := p.Remaining(at(10)); got != 15*time.Minute {
+		t.Errorf("Remaining = %v, want 15m", got)
+	}
+	if _, ok := p.Advance(at(24)); ok {
+		t.Fatal("work interval finished early")
+	}
+
+	// Switching tasks mid-interval credits the new one
+	p.Work(8, at(20))
+	done, ok := p.Advance(at(25))
+	if !ok || done.taskID != 8 || !done.started.Equal(start) || !done.end.Equal(at(25)) {
+		t.Fatalf("Advance = %+v, %v; want interval for task 8 from 9:00 to 9:25", done, ok)
+	}
+	if p.phase != phaseShortBreak {
+		t.Errorf("phase after first interval = %d, want short break", p.phase)
+	}
+
+	// Linking a task during a break doesn't restart work
+	p.Work(8, at(27))
+	if p.phase != phaseShortBreak {
+		t.Errorf("Work during a break changed the phase to %d", p.phase)
+	}
+
+	if _, ok := p.Advance(at(30)); ok || p.phase != phaseWork {
+		t.Fatalf("break didn't roll into work: phase %d", p.phase)
+	}
+	if _, ok := p.Advance(at(55)); !ok {
+		t.Fatal("second interval didn't finish")
+	}
+	if p.phase != phaseLongBreak || p.Remaining(at(55)) != 15*time.Minute {
+		t.Errorf("phase after second interval = %d, want a 15m long break", p.phase)
+	}
+}
+
+func TestPomodoroDefaults(t *testing.T) {
+	p := NewPomodoro(&config.Config{PomodoroWork: -time.Minute, PomodoroLongBreak: 20 * time.Minute})
+	if p.work != config.DefaultPomodoroWork || p.shortBreak != config.DefaultPomodoroShortBreak || p.longBreak != 20*time.Minute {
+		t.Errorf("lengths = %v/%v/%v, want the defaults for the ones not set", p.work, p.shortBreak, p.longBreak)
+	}
+
+	// A work interval still runs its full length
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	p.Work(7, start)
+	if _, ok := p.Advance(start); ok {
+		t.Error("work interval ended as soon as it started")
+	}
+}
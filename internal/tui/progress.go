@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yagnikpt/kairos/internal/ai"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+type milestoneState int
+
+const (
+	milestoneQueued milestoneState = iota
+	milestoneRunning
+	milestoneDone
+	milestoneFailed
+)
+
+type milestoneStartedMsg int
+type milestoneDoneMsg ai.SubTaskResult
+type planDoneMsg []ai.SubTaskResult
+
+type progressModel struct {
+	titles  []string
+	states  []milestoneState
+	counts  []int
+	spinner spinner.Model
+	cancel  context.CancelFunc
+	results []ai.SubTaskResult
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.cancel()
+		}
+		return m, nil
+
+	case milestoneStartedMsg:
+		m.states[msg] = milestoneRunning
+		return m, nil
+
+	case milestoneDoneMsg:
+		if msg.Err != nil {
+			m.states[msg.Index] = milestoneFailed
+		} else {
+			m.states[msg.Index] = milestoneDone
+			m.counts[msg.Index] = len(msg.Subtasks)
+		}
+		return m, nil
+
+	case planDoneMsg:
+		m.results = msg
+		return m, tea.Quit
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	var b strings.Builder
+	b.WriteString(ui.TitleStyle.Render("Generating detailed plan..."))
+	b.WriteString("\n")
+
+	faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+	done := lipgloss.NewStyle().Foreground(ui.SecondaryColor)
+	failed := lipgloss.NewStyle().Foreground(ui.AccentColor)
+
+	for i, title := range m.titles {
+		switch m.states[i] {
+		case milestoneQueued:
+			fmt.Fprintf(&b, "%s %s\n", faint.Render("·"), faint.Render(title))
+		case milestoneRunning:
+			fmt.Fprintf(&b, "%s%s\n", m.spinner.View(), title)
+		case milestoneDone:
+			fmt.Fprintf(&b, "%s %s %s\n", done.Render("✓"), title, faint.Render(fmt.Sprintf("(%d subtasks)", m.counts[i])))
+		case milestoneFailed:
+			fmt.Fprintf(&b, "%s %s %s\n", failed.Render("✗"), title, faint.Render("(needs planning)"))
+		}
+	}
+	return b.String()
+}
+
+// RunPlanProgress generates subtasks for every milestone title with at most
+// limit requests in flight, showing live per-milestone progress. Ctrl-C
+// cancels the outstanding requests and returns context.Canceled.
+func RunPlanProgress(ctx context.Context, planner ai.Planner, titles []string, limit int) ([]ai.SubTaskResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := spinner.New()
+	s.Spinner = spinner.MiniDot
+	s.Style = lipgloss.NewStyle().Foreground(ui.PrimaryColor)
+
+	m := progressModel{
+		titles:  titles,
+		states:  make([]milestoneState, len(titles)),
+		counts:  make([]int, len(titles)),
+		spinner: s,
+		cancel:  cancel,
+	}
+
+	p := tea.NewProgram(m)
+	go func() {
+		results := ai.GenerateSubTasksConcurrently(ctx, planner, titles, limit,
+			func(i int) { p.Send(milestoneStartedMsg(i)) },
+			func(r ai.SubTaskResult) { p.Send(milestoneDoneMsg(r)) },
+		)
+		p.Send(planDoneMsg(results))
+	}()
+
+	final, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return final.(progressModel).results, nil
+}
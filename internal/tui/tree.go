@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yagnikpt/kairos/internal/models"
+	"github.com/yagnikpt/kairos/internal/ui"
+)
+
+type treeKeyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	Expand       key.Binding
+	Collapse     key.Binding
+	Toggle       key.Binding
+	Jump         key.Binding
+	AddMilestone key.Binding
+	AddSubtask   key.Binding
+	Edit         key.Binding
+	Delete       key.Binding
+	MoveUp       key.Binding
+	MoveDown     key.Binding
+	Split        key.Binding
+	Back         key.Binding
+	Quit         key.Binding
+	Help         key.Binding
+}
+
+func (k treeKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Toggle, k.Jump, k.Back, k.Quit, k.Help}
+}
+
+func (k treeKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.Expand, k.Collapse, k.Toggle},
+		{k.AddMilestone, k.AddSubtask, k.Edit, k.Delete, k.MoveUp, k.MoveDown, k.Split},
+		{k.Jump, k.Back, k.Quit, k.Help},
+	}
+}
+
+var treeKeys = treeKeyMap{
+	Up:           key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:         key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Expand:       key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "expand")),
+	Collapse:     key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "collapse")),
+	Toggle:       key.NewBinding(key.WithKeys(" ", "tab"), key.WithHelp("space", "expand/collapse")),
+	Jump:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "focus here")),
+	AddMilestone: key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "add milestone")),
+	AddSubtask:   key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "add subtask")),
+	Edit:         key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "rename")),
+	Delete:       key.NewBinding(key.WithKeys("delete", "backspace"), key.WithHelp("del", "delete")),
+	MoveUp:       key.NewBinding(key.WithKeys("K"), key.WithHelp("K", "move up")),
+	MoveDown:     key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "move down")),
+	Split:        key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "explode into subtasks")),
+	Back:         key.NewBinding(key.WithKeys("t", "esc"), key.WithHelp("t", "back")),
+	Quit:         key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+	Help:         key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+}
+
+// goalTree is every task of a goal laid out as a collapsible tree.
+type goalTree struct {
+	tasks    map[int64]*models.Task
+	children map[int64][]int64 // Parent ID (0 for milestones) to children in plan order
+	expanded map[int64]bool
+	cursor   int
+}
+
+// treeRow addresses one visible line of the tree.
+type treeRow struct {
+	task  *models.Task
+	depth int
+}
+
+func newGoalTree(tasks []models.Task) goalTree {
+	t := goalTree{
+		tasks:    map[int64]*models.Task{},
+		children: map[int64][]int64{},
+		expanded: map[int64]bool{},
+	}
+	t.setTasks(tasks)
+	return t
+}
+
+// setTasks replaces the tasks shown, keeping what's expanded.
+func (t *goalTree) setTasks(tasks []models.Task) {
+	clear(t.tasks)
+	clear(t.children)
+	for i := range tasks {
+		task := &tasks[i]
+		t.tasks[task.ID] = task
+		parent := task.ParentTaskID.Int64 // 0 for milestones
+		t.children[parent] = append(t.children[parent], task.ID)
+	}
+	t.cursor = min(t.cursor, max(len(t.rows())-1, 0))
+}
+
+func (t goalTree) rows() []treeRow {
+	var rows []treeRow
+	var walk func(parent int64, depth int)
+	walk = func(parent int64, depth int) {
+		for _, id := range t.children[parent] {
+			rows = append(rows, treeRow{task: t.tasks[id], depth: depth})
+			if t.expanded[id] {
+				walk(id, depth+1)
+			}
+		}
+	}
+	walk(0, 0)
+	return rows
+}
+
+func (t goalTree) current() (treeRow, bool) {
+	rows := t.rows()
+	if t.cursor < 0 || t.cursor >= len(rows) {
+		return treeRow{}, false
+	}
+	return rows[t.cursor], true
+}
+
+// focus moves the cursor to a task, expanding its ancestors.
+func (t *goalTree) focus(id int64) {
+	for p := t.tasks[id]; p != nil && p.ParentTaskID.Valid; p = t.tasks[p.ParentTaskID.Int64] {
+		t.expanded[p.ParentTaskID.Int64] = true
+	}
+	for i, r := range t.rows() {
+		if r.task.ID == id {
+			t.cursor = i
+			return
+		}
+	}
+}
+
+// below lists the tasks under a task in plan order, expanded or not.
+func (t goalTree) below(id int64) []models.Task {
+	var tasks []models.Task
+	for _, child := range t.children[id] {
+		tasks = append(tasks, *t.tasks[child])
+		tasks = append(tasks, t.below(child)...)
+	}
+	return tasks
+}
+
+// depth counts the tasks above a task: 0 for milestones.
+func (t goalTree) depth(id int64) int {
+	n := 0
+	for task := t.tasks[id]; task != nil && task.ParentTaskID.Valid; task = t.tasks[task.ParentTaskID.Int64] {
+		n++
+	}
+	return n
+}
+
+// parents lists the descriptions of the tasks above a task, milestone first.
+func (t goalTree) parents(id int64) []string {
+	var names []string
+	for task := t.tasks[id]; task != nil && task.ParentTaskID.Valid; {
+		task = t.tasks[task.ParentTaskID.Int64]
+		if task != nil {
+			names = append([]string{task.Description}, names...)
+		}
+	}
+	return names
+}
+
+// milestoneOf returns the milestone a task belongs to.
+func (t goalTree) milestoneOf(id int64) int64 {
+	task := t.tasks[id]
+	for task != nil && task.ParentTaskID.Valid {
+		id = task.ParentTaskID.Int64
+		task = t.tasks[id]
+	}
+	return id
+}
+
+// progress counts the resolved (done or skipped) leaf tasks under a task.
+func (t goalTree) progress(id int64) (done, total int) {
+	for _, child := range t.children[id] {
+		if len(t.children[child]) > 0 {
+			d, n := t.progress(child)
+			done, total = done+d, total+n
+			continue
+		}
+		total++
+		if status := t.tasks[child].Status; status == "DONE" || status == "SKIPPED" {
+			done++
+		}
+	}
+	return done, total
+}
+
+// progressBar renders done out of total as a bar of the given width.
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return lipgloss.NewStyle().Foreground(ui.SecondaryColor).Render(strings.Repeat("█", filled)) +
+		lipgloss.NewStyle().Foreground(ui.FaintColor).Render(strings.Repeat("░", width-filled))
+}
+
+// lines renders the visible rows. focusID is the milestone shown in focus
+// mode and workingID the task being worked on.
+func (t goalTree) lines(focusID, workingID int64) []string {
+	faint := lipgloss.NewStyle().Foreground(ui.SubTextColor)
+	warn := lipgloss.NewStyle().Foreground(ui.AccentColor)
+
+	var lines []string
+	for i, r := range t.rows() {
+		task := r.task
+		indent := strings.Repeat("    ", r.depth)
+
+		var text string
+		if len(t.children[task.ID]) > 0 {
+			arrow := "▸"
+			if t.expanded[task.ID] {
+				arrow = "▾"
+			}
+			done, total := t.progress(task.ID)
+			text = fmt.Sprintf("%s%s %s  %s %s", indent, arrow, task.Description,
+				progressBar(done, total, 10), faint.Render(fmt.Sprintf("%d/%d", done, total)))
+		} else {
+			mark := "[ ]"
+			switch {
+			case task.Status == "DONE":
+				mark = "[x]"
+			case task.Status == "SKIPPED":
+				mark = "[-]"
+			case task.ID == workingID:
+				mark = "[>]"
+			}
+			text = fmt.Sprintf("%s%s %s", indent, mark, task.Description)
+			if task.NeedsPlanning {
+				text += warn.Render("  needs planning")
+			}
+		}
+		text += faint.Render(fmt.Sprintf("  #%d", task.ID))
+		if task.ID == focusID {
+			text += faint.Render("  ← focus")
+		}
+
+		if i == t.cursor {
+			lines = append(lines, ui.SelectedStyle.Render("> "+text))
+		} else {
+			lines = append(lines, ui.ItemStyle.Render("  "+text))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, warn.Render("This goal has no milestones."))
+	}
+	return lines
+}
@@ -90,3 +90,16 @@ func RenderSuccess(text string) {
 func RenderStatus(label, value string) {
 	fmt.Printf("%s %s\n", SubtitleStyle.Render(label), StatusStyle.Render(value))
 }
+
+// FormatDuration renders minutes compactly, e.g. "45m", "2h" or "1h30m".
+func FormatDuration(mins int) string {
+	h, m := mins/60, mins%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	default:
+		return fmt.Sprintf("%dh%dm", h, m)
+	}
+}